@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// runArchive exports, imports or locates the download archive of a preset.
+func runArchive(cfg util.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: telecharger archive export|import|path [-preset name] [file]")
+	}
+
+	flags := flag.NewFlagSet("archive "+args[0], flag.ContinueOnError)
+	preset := flags.String("preset", "", "preset whose archive to use, the default archive when empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if len(*preset) > 0 {
+		if _, ok := cfg.Preset(*preset); !ok {
+			return fmt.Errorf("unknown preset %q", *preset)
+		}
+	}
+
+	switch args[0] {
+	case "path":
+		path, err := util.ArchivePath(*preset)
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	case "export":
+		var w io.Writer = os.Stdout
+		if flags.NArg() > 0 {
+			file, err := os.Create(flags.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+		return util.ExportArchive(*preset, w)
+	case "import":
+		var r io.Reader = os.Stdin
+		if flags.NArg() > 0 {
+			file, err := os.Open(flags.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			r = file
+		}
+		added, err := util.ImportArchive(*preset, r)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported %d new archive entries\n", added)
+		return nil
+	default:
+		return fmt.Errorf("unknown archive command %q, expected export, import or path", args[0])
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// command is a subcommand of the telecharger binary.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(cfg util.Config, args []string) error
+}
+
+// commands are the subcommands available from the command line, running
+// telecharger without one starts the TUI.
+var commands = []command{
+	{
+		name:    "config",
+		usage:   "config show|check|path|init [-force]",
+		summary: "show, check, locate or create the config file",
+		run:     runConfig,
+	},
+	{
+		name:    "queue",
+		usage:   "queue export|import [-format f] [-status s] [-preset name] [-dry-run] [file]",
+		summary: "export the queue and history or import them, or a batch file",
+		run:     runQueue,
+	},
+	{
+		name:    "archive",
+		usage:   "archive export|import|path [-preset name] [file]",
+		summary: "manage the yt-dlp download archive of a preset",
+		run:     runArchive,
+	},
+	{
+		name:    "subs",
+		usage:   "subs add|list|remove|check [flags] [URL|ID]",
+		summary: "manage channel and playlist subscriptions",
+		run:     runSubscriptions,
+	},
+	{
+		name:    "feeds",
+		usage:   "feeds add|list|remove|check [flags] [URL|ID]",
+		summary: "manage RSS and Atom feeds that downloads are queued from",
+		run:     runFeeds,
+	},
+	{
+		name:    "podcast",
+		usage:   "podcast [serve] [-title t] [-preset name] [-folder dir] [-base-url url]",
+		summary: "write or serve a podcast feed of completed audio downloads",
+		run:     runPodcast,
+	},
+	{
+		name:    "stats",
+		usage:   "stats [-days n] [-weeks n] [-width n]",
+		summary: "show download activity, speeds, failures, top sources and disk usage",
+		run:     runStats,
+	},
+}
+
+// Run executes the subcommand named by the first argument.
+func Run(cfg util.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given")
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(cfg, args[1:])
+		}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		Usage(os.Stdout)
+		return nil
+	}
+
+	Usage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// Usage prints the global flags and the list of subcommands.
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: telecharger [-config file] [command]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run without a command to start the dashboard.")
+	fmt.Fprintf(w, "Settings can be overridden with %s* environment variables, such as %s.\n", util.EnvPrefix, util.EnvName("download_folder"))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-55s %s\n", c.usage, c.summary)
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig shows, checks, locates or creates the config file.
+func runConfig(cfg util.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: telecharger config show|check|path|init [-force]")
+	}
+
+	flags := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	force := flags.Bool("force", false, "replace an existing config file with the defaults")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "path":
+		path, err := util.ConfigFilePath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	case "show":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(cfg); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "check":
+		path, err := util.ConfigFilePath()
+		if err != nil {
+			return err
+		}
+		if _, err := util.ParseConfig(); err != nil {
+			var issues util.ConfigIssues
+			if errors.As(err, &issues) {
+				for _, issue := range issues {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", path, issue)
+				}
+				if len(issues) == 1 {
+					return fmt.Errorf("1 problem found")
+				}
+				return fmt.Errorf("%d problems found", len(issues))
+			}
+			return err
+		}
+		fmt.Printf("%s: ok\n", path)
+		return nil
+	case "init":
+		path, err := util.InitConfigFile(*force)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("wrote the default config to %s\n", path)
+		return nil
+	}
+
+	return fmt.Errorf("unknown config command %q", args[0])
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/feeds"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// runFeeds adds, lists, removes and checks RSS and Atom feeds.
+func runFeeds(cfg util.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: telecharger feeds add|list|remove|check")
+	}
+
+	switch args[0] {
+	case "add":
+		flags := flag.NewFlagSet("feeds add", flag.ContinueOnError)
+		preset := flags.String("preset", "", "preset to queue new entries with")
+		titleRegex := flags.String("title", "", "only queue entries whose title matches this regular expression")
+		dateAfter := flags.String("after", "", "only queue entries published on or after this date (YYYYMMDD)")
+		enclosures := flags.Bool("enclosures", false, "download the enclosure rather than the entry's page, for podcasts")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: telecharger feeds add [flags] URL")
+		}
+
+		feed := util.FeedConfig{
+			URL:        flags.Arg(0),
+			Preset:     *preset,
+			TitleRegex: *titleRegex,
+			After:      *dateAfter,
+			Enclosures: *enclosures,
+		}
+		if err := feeds.Validate(cfg, feed); err != nil {
+			return err
+		}
+		id, err := data.InsertFeed(data.Feed{
+			Url:        feed.URL,
+			Preset:     feed.Preset,
+			TitleRegex: feed.TitleRegex,
+			DateAfter:  feed.After,
+			Enclosures: feed.Enclosures,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("added feed %d\n", id)
+		return nil
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tURL\tPRESET\tTITLE\tAFTER\tENCLOSURES")
+		for _, feed := range cfg.Feeds {
+			fmt.Fprintf(w, "config\t%s\t%s\t%s\t%s\t%t\n", feed.URL, feed.Preset, feed.TitleRegex, feed.After, feed.Enclosures)
+		}
+		stored, err := data.GetAllFeeds()
+		if err != nil {
+			return err
+		}
+		for _, feed := range stored {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\n", feed.Id, feed.Url, feed.Preset, feed.TitleRegex, feed.DateAfter, feed.Enclosures)
+		}
+		return w.Flush()
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: telecharger feeds remove ID")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid feed id %q, feeds from the config file are removed by editing it", args[1])
+		}
+		return data.DeleteFeed(id)
+	case "check":
+		all, err := feeds.All(cfg)
+		if err != nil {
+			return err
+		}
+		for _, feed := range all {
+			if len(args) > 1 && args[1] != feed.URL {
+				continue
+			}
+			added, err := feeds.Check(cfg, feed)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", feed.URL, err)
+				continue
+			}
+			fmt.Printf("%s: queued %d new entries\n", feed.URL, added)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown feeds command %q, expected add, list, remove or check", args[0])
+	}
+}
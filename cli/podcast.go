@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/jim-at-jibba/telecharger/podcast"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// runPodcast writes or serves a podcast feed of completed audio downloads.
+func runPodcast(cfg util.Config, args []string) error {
+	serve := len(args) > 0 && args[0] == "serve"
+	if serve {
+		args = args[1:]
+	}
+
+	flags := flag.NewFlagSet("podcast", flag.ContinueOnError)
+	title := flags.String("title", "telecharger", "title of the podcast")
+	preset := flags.String("preset", "", "only include downloads made with this preset")
+	folder := flags.String("folder", "", "only include files saved under this folder")
+	baseURL := flags.String("base-url", "", "address the files are served from, defaults to the serving address")
+	output := flags.String("o", "", "file to write the feed to instead of stdout")
+	addr := flags.String("addr", ":8080", "address to listen on when serving")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*preset) > 0 {
+		if _, ok := cfg.Preset(*preset); !ok {
+			return fmt.Errorf("unknown preset %q", *preset)
+		}
+	}
+
+	opts := podcast.Options{
+		Title:   *title,
+		Preset:  *preset,
+		Folder:  *folder,
+		BaseURL: *baseURL,
+	}
+
+	if serve {
+		listener, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		fmt.Println("Serving the podcast feed, subscribe to one of:")
+		for _, host := range lanAddresses() {
+			fmt.Printf("  http://%s:%d/feed.xml\n", host, port)
+		}
+		return http.Serve(listener, podcast.Handler(cfg, opts))
+	}
+
+	if len(opts.BaseURL) == 0 {
+		return fmt.Errorf("-base-url is needed to write a feed, or use podcast serve")
+	}
+
+	episodes, err := podcast.Episodes(cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if len(*output) > 0 {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return podcast.Write(w, opts, episodes)
+}
+
+// lanAddresses returns the IPv4 addresses other devices on the network can
+// reach this machine on, falling back to localhost.
+func lanAddresses() []string {
+	hosts := []string{}
+	addresses, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, address := range addresses {
+			ipNet, ok := address.(*net.IPNet)
+			if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+				continue
+			}
+			hosts = append(hosts, ipNet.IP.String())
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, "localhost")
+	}
+	return hosts
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/queuefile"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// runQueue exports the queue and history to a file or imports them from one.
+func runQueue(cfg util.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: telecharger queue export|import [flags] [file]")
+	}
+
+	flags := flag.NewFlagSet("queue "+args[0], flag.ContinueOnError)
+	format := flags.String("format", "", "json, csv, urls or batch (import only), guessed from the file name when empty")
+	preset := flags.String("preset", "", "export: only items of this preset, import: preset for items without one")
+	status := flags.String("status", "", "export: comma separated statuses to include, all when empty")
+	dryRun := flags.Bool("dry-run", false, "import: report what would be added without changing the queue")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if len(*preset) > 0 {
+		if _, ok := cfg.Preset(*preset); !ok {
+			return fmt.Errorf("unknown preset %q", *preset)
+		}
+	}
+
+	switch args[0] {
+	case "export":
+		return exportQueue(flags.Arg(0), *format, *status, *preset)
+	case "import":
+		return importQueue(cfg, flags.Arg(0), *format, *preset, *dryRun)
+	default:
+		return fmt.Errorf("unknown queue command %q, expected export or import", args[0])
+	}
+}
+
+func exportQueue(path, format, status, preset string) error {
+	statuses := data.Statuses
+	if len(status) > 0 {
+		statuses = strings.Split(status, ",")
+		for i, s := range statuses {
+			statuses[i] = strings.TrimSpace(s)
+			if !containsStatus(statuses[i]) {
+				return fmt.Errorf("unknown status %q, expected one of %s", s, strings.Join(data.Statuses, ", "))
+			}
+		}
+	}
+
+	items, err := data.GetAllQueueItems(statuses...)
+	if err != nil {
+		return err
+	}
+	if len(preset) > 0 {
+		filtered := []*data.QueueItem{}
+		for _, item := range items {
+			if item.Preset == preset {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	if len(format) == 0 {
+		format = queuefile.JSON
+		if len(path) > 0 {
+			format = queuefile.FormatFromPath(path)
+		}
+		if format == queuefile.Batch {
+			format = queuefile.URLs
+		}
+	}
+	if format == queuefile.Batch {
+		return fmt.Errorf("batch files can only be imported, use urls to export a list of URLs")
+	}
+
+	var w io.Writer = os.Stdout
+	if len(path) > 0 {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return queuefile.Export(w, format, items)
+}
+
+func importQueue(cfg util.Config, path, format, preset string, dryRun bool) error {
+	var r io.Reader = os.Stdin
+	if len(path) > 0 {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	if len(format) == 0 {
+		format = queuefile.Batch
+		if len(path) > 0 {
+			format = queuefile.FormatFromPath(path)
+		}
+	}
+
+	records, err := queuefile.Read(r, format)
+	if err != nil {
+		return err
+	}
+	plan, err := queuefile.NewPlan(cfg, records, preset)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range plan.Existing {
+		fmt.Printf("skip %s (already in the queue)\n", url)
+	}
+	for _, url := range plan.Repeated {
+		fmt.Printf("skip %s (repeated in the file)\n", url)
+	}
+	if dryRun {
+		for _, item := range plan.Add {
+			fmt.Printf("add  %s (%s)\n", item.VideoId, importStatus(item))
+		}
+		fmt.Printf("dry run: %s\n", plan.Summary())
+		return nil
+	}
+
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	fmt.Printf("imported %s\n", plan.Summary())
+	return nil
+}
+
+func importStatus(item data.QueueItem) string {
+	if len(item.Status) == 0 {
+		return "queued"
+	}
+	return item.Status
+}
+
+func containsStatus(status string) bool {
+	for _, s := range data.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
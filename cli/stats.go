@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/stats"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// runStats prints a summary of the download history with charts.
+func runStats(cfg util.Config, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	days := flags.Int("days", stats.DefaultDays, "number of days in the daily chart")
+	weeks := flags.Int("weeks", stats.DefaultWeeks, "number of weeks in the weekly chart")
+	width := flags.Int("width", 100, "width of the charts in columns")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *days < 1 || *weeks < 1 {
+		return fmt.Errorf("-days and -weeks must be 1 or more")
+	}
+
+	report, err := stats.Collect(time.Now(), *days, *weeks)
+	if err != nil {
+		return err
+	}
+	output := stats.Render(report, *width)
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		output = ansiEscape.ReplaceAllString(output, "")
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// ansiEscape matches the styling left out when the output isn't a terminal.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
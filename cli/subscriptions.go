@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/subscriptions"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// runSubscriptions adds, lists, removes and checks channel and playlist subscriptions.
+func runSubscriptions(cfg util.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: telecharger subs add|list|remove|check")
+	}
+
+	switch args[0] {
+	case "add":
+		flags := flag.NewFlagSet("subs add", flag.ContinueOnError)
+		preset := flags.String("preset", "", "preset to queue new videos with")
+		titleRegex := flags.String("title", "", "only queue videos whose title matches this regular expression")
+		minDuration := flags.Int("min-duration", 0, "only queue videos at least this many seconds long")
+		dateAfter := flags.String("after", "", "only queue videos uploaded on or after this date (YYYYMMDD)")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: telecharger subs add [flags] URL")
+		}
+
+		subscription, err := subscriptions.New(cfg, flags.Arg(0), *preset, *titleRegex, *minDuration, *dateAfter)
+		if err != nil {
+			return err
+		}
+		id, err := data.InsertSubscription(subscription)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("added subscription %d\n", id)
+		return nil
+	case "list":
+		subs, err := data.GetAllSubscriptions()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tURL\tPRESET\tTITLE\tMIN DURATION\tAFTER\tLAST CHECKED")
+		for _, subscription := range subs {
+			lastChecked := "never"
+			if subscription.LastChecked.Valid {
+				lastChecked = subscription.LastChecked.Time.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				subscription.Id,
+				subscription.Url,
+				subscription.Preset,
+				subscription.TitleRegex,
+				subscription.MinDuration,
+				subscription.DateAfter,
+				lastChecked,
+			)
+		}
+		return w.Flush()
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: telecharger subs remove ID")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid subscription id %q", args[1])
+		}
+		return data.DeleteSubscription(id)
+	case "check":
+		subs, err := data.GetAllSubscriptions()
+		if err != nil {
+			return err
+		}
+		for _, subscription := range subs {
+			if len(args) > 1 && args[1] != strconv.Itoa(subscription.Id) {
+				continue
+			}
+			added, err := subscriptions.Check(cfg, subscription)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", subscription.Url, err)
+				continue
+			}
+			fmt.Printf("%s: queued %d new videos\n", subscription.Url, added)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown subs command %q, expected add, list, remove or check", args[0])
+	}
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"log"
+	"time"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// CreateClipboardTables creates the table of URLs that were copied to the
+// clipboard and dismissed, so they aren't offered for download again.
+func CreateClipboardTables() {
+	createTableSQL := `CREATE TABLE IF NOT EXISTS dismissed_urls (
+		"VideoKey" TEXT NOT NULL PRIMARY KEY,
+		"Url" TEXT NOT NULL,
+		"DismissedAt" DATETIME NOT NULL
+	  );`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// DismissURL remembers that url shouldn't be offered from the clipboard again.
+func DismissURL(url string) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO dismissed_urls(VideoKey, Url, DismissedAt) VALUES (?, ?, ?)`, util.VideoKey(url), url, time.Now())
+	return err
+}
+
+// IsURLDismissed reports whether url, in any of its forms, was dismissed.
+func IsURLDismissed(url string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM dismissed_urls WHERE VideoKey = ?`, util.VideoKey(url)).Scan(&count)
+	return count > 0, err
+}
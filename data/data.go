@@ -5,53 +5,161 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type QueueItem struct {
-	Id             int
-	VideoId        string
-	OutputName     string
-	EmbedThumbnail bool
-	AudioOnly      bool
-	AudioFormat    string
-	ExtraCommands  string
-	Status         string
+	Id            int
+	VideoId       string
+	OutputName    string
+	AudioFormat   string
+	ExtraCommands string
+	Status        string
+	Priority      int
+	Position      int
+	Preset        string
+	VideoKey      string
+	Title         string
+	Uploader      string
+	Duration      float64
+	Thumbnail     string
+	FilePath      string
+	FileSize      int64
+	Extractor     string
+	WebpageUrl    string
+	StartedAt     sql.NullTime
+	CompletedAt   sql.NullTime
+	NotBefore     sql.NullTime
+	RateLimit     string
+	// ArchivedAt is when a completed item left the Done list, it stays in
+	// the history.
+	ArchivedAt sql.NullTime
+	// ErrorMessage says why a download failed, when that is known.
+	ErrorMessage string
+	// Options are the values of the registered yt-dlp options, including
+	// embedding the thumbnail and keeping only the audio.
+	Options ytdlp.Options
+	Tags    []string
+}
+
+// Metadata is what yt-dlp reported about a finished download.
+type Metadata struct {
+	Title      string
+	Uploader   string
+	Duration   float64
+	Thumbnail  string
+	FilePath   string
+	FileSize   int64
+	Extractor  string
+	WebpageUrl string
 }
 
 var db *sql.DB
 
-func OpenDatabase() error {
-	var err error
+// dbPath is the file of the open database.
+var dbPath string
+
+// DatabaseFileName is the name of the database file in the data directory.
+const DatabaseFileName = util.DatabaseFile
+
+// databaseFile is the database given with --db, used whatever the settings say.
+var databaseFile string
 
+// SetDatabaseFile makes Open use the database at path.
+func SetDatabaseFile(path string) {
+	databaseFile = path
+}
+
+// DatabasePath returns the database file to use with settings: the one given
+// with --db, then the database_path setting, then the development database when
+// DEBUG is set and otherwise the one in the data directory of the profile.
+func DatabasePath(settings util.SettingsConfig) (string, error) {
+	if len(databaseFile) > 0 {
+		return util.ExpandHome(databaseFile), nil
+	}
+	if len(settings.DatabasePath) > 0 {
+		return util.ExpandHome(settings.DatabasePath), nil
+	}
 	if len(os.Getenv("DEBUG")) > 0 {
-		db, err = sql.Open("sqlite3", "./sqlite-database-dev.db")
-	} else {
-		dirname, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatal(err)
-		}
-		path := fmt.Sprintf("%s/telecharger", dirname)
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			err := os.Mkdir(path, os.ModePerm)
-			if err != nil {
-				log.Print(err.Error())
-			}
+		if util.Profile() != util.DefaultProfile {
+			return fmt.Sprintf("./sqlite-database-dev-%s.db", util.Profile()), nil
 		}
+		return "./sqlite-database-dev.db", nil
+	}
 
-		db, err = sql.Open("sqlite3", fmt.Sprintf("%s/sqlite-database.db", path))
-		if err != nil {
-			log.Print(err.Error())
-			return err
-		}
+	dir, err := util.AppDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DatabaseFileName), nil
+}
+
+// OpenDatabase opens the database at path, creating its directory if needed.
+// It replaces the database already open, which stays in use if the new one
+// can't be opened.
+func OpenDatabase(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
 	}
+
+	opened, err := sql.Open("sqlite3", path)
 	if err != nil {
 		log.Print(err.Error())
 		return err
 	}
+	if err := opened.Ping(); err != nil {
+		opened.Close()
+		return err
+	}
 
-	return db.Ping()
+	if db != nil {
+		db.Close()
+	}
+	db = opened
+	dbPath = path
+	return nil
+}
+
+// Path returns the file of the open database, which download logs are kept
+// by.
+func Path() string {
+	return dbPath
+}
+
+// Open opens the database for settings and creates or migrates its tables.
+func Open(settings util.SettingsConfig) error {
+	path, err := DatabasePath(settings)
+	if err != nil {
+		return err
+	}
+	if err := OpenDatabase(path); err != nil {
+		return fmt.Errorf("can't open the database %s: %v", path, err)
+	}
+	CreateQueueTable()
+	CreateTagTables()
+	CreateHistoryTables()
+	CreateSubscriptionTables()
+	CreateFeedTables()
+	CreateClipboardTables()
+	return nil
+}
+
+// CloseDatabase closes the database.
+func CloseDatabase() error {
+	if db == nil {
+		return nil
+	}
+	err := db.Close()
+	db = nil
+	dbPath = ""
+	return err
 }
 
 func CreateQueueTable() {
@@ -75,34 +183,372 @@ func CreateQueueTable() {
 	if err != nil {
 		log.Fatalln(err)
 	}
+
+	addColumnIfMissing("queue", "Priority", "INTEGER NOT NULL DEFAULT 0")
+	if addColumnIfMissing("queue", "Position", "INTEGER NOT NULL DEFAULT 0") {
+		// keep the existing insertion order for rows created before positions existed
+		if _, err := db.Exec(`UPDATE queue SET Position = Id`); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	addColumnIfMissing("queue", "Preset", "TEXT NOT NULL DEFAULT ''")
+	if addColumnIfMissing("queue", "VideoKey", "TEXT NOT NULL DEFAULT ''") {
+		backfillVideoKeys()
+	}
+	addColumnIfMissing("queue", "Title", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "Uploader", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "Duration", "REAL NOT NULL DEFAULT 0")
+	addColumnIfMissing("queue", "Thumbnail", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "FilePath", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "FileSize", "INTEGER NOT NULL DEFAULT 0")
+	addColumnIfMissing("queue", "Extractor", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "WebpageUrl", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "StartedAt", "DATETIME")
+	addColumnIfMissing("queue", "CompletedAt", "DATETIME")
+	addColumnIfMissing("queue", "NotBefore", "DATETIME")
+	addColumnIfMissing("queue", "RateLimit", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("queue", "ArchivedAt", "DATETIME")
+	addColumnIfMissing("queue", "ErrorMessage", "TEXT NOT NULL DEFAULT ''")
+	for _, option := range ytdlp.Registered() {
+		addColumnIfMissing("queue", optionColumn(option), optionColumnDefinition(option))
+	}
 }
 
-func InsertQueueItem(videoId, outputName, audioFormat, extraCommnds string, embedThumbnail, audioOnly bool) error {
-	insertNoteSQL := `INSERT INTO queue(videoId, outputName, audioFormat, extraCommands, embedThumbnail, audioOnly, status) VALUES (?, ?, ?, ?, ?, ?, ?)`
-	statement, err := db.Prepare(insertNoteSQL)
+// optionColumn returns the column an option is stored in, its name in camel
+// case so embed_thumbnail is stored in EmbedThumbnail.
+func optionColumn(option ytdlp.Option) string {
+	words := strings.Split(option.Name, "_")
+	for i, word := range words {
+		if len(word) > 0 {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// optionColumnDefinition returns the SQL type of an option's column, with the
+// option's default for rows that were there before the option.
+func optionColumnDefinition(option ytdlp.Option) string {
+	switch option.Type {
+	case ytdlp.BoolOption:
+		if on, _ := strconv.ParseBool(option.Default); on {
+			return "BOOL NOT NULL DEFAULT 1"
+		}
+		return "BOOL NOT NULL DEFAULT 0"
+	case ytdlp.NumberOption:
+		n, _ := strconv.Atoi(option.Default)
+		return fmt.Sprintf("INTEGER NOT NULL DEFAULT %d", n)
+	default:
+		return fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", strings.ReplaceAll(option.Default, "'", "''"))
+	}
+}
+
+// optionColumns returns the quoted columns of the registered options, in
+// registry order.
+func optionColumns() []string {
+	columns := []string{}
+	for _, option := range ytdlp.Registered() {
+		columns = append(columns, fmt.Sprintf(`"%s"`, optionColumn(option)))
+	}
+	return columns
+}
+
+// optionValues returns the values stored in the option columns for options.
+func optionValues(options ytdlp.Options) []interface{} {
+	values := []interface{}{}
+	for _, option := range ytdlp.Registered() {
+		switch option.Type {
+		case ytdlp.BoolOption:
+			values = append(values, options.Bool(option.Name))
+		case ytdlp.NumberOption:
+			n, _ := strconv.Atoi(options.Value(option.Name))
+			values = append(values, n)
+		default:
+			values = append(values, options.Value(option.Name))
+		}
+	}
+	return values
+}
+
+// optionTargets returns what the option columns are scanned into, read back by
+// scannedOptions.
+func optionTargets() []interface{} {
+	targets := []interface{}{}
+	for _, option := range ytdlp.Registered() {
+		switch option.Type {
+		case ytdlp.BoolOption:
+			targets = append(targets, new(bool))
+		case ytdlp.NumberOption:
+			targets = append(targets, new(int64))
+		default:
+			targets = append(targets, new(string))
+		}
+	}
+	return targets
+}
+
+// scannedOptions turns the scanned option columns back into options.
+func scannedOptions(targets []interface{}) ytdlp.Options {
+	options := ytdlp.Options{}
+	for i, option := range ytdlp.Registered() {
+		switch target := targets[i].(type) {
+		case *bool:
+			options.Set(option.Name, strconv.FormatBool(*target))
+		case *int64:
+			options.Set(option.Name, strconv.FormatInt(*target, 10))
+		case *string:
+			options.Set(option.Name, *target)
+		}
+	}
+	return options
+}
+
+// backfillVideoKeys works out the video key of rows created before keys were stored.
+func backfillVideoKeys() {
+	row, err := db.Query(`SELECT Id, VideoId FROM queue`)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	_, err = statement.Exec(videoId, outputName, audioFormat, extraCommnds, embedThumbnail, audioOnly, "queued")
+	keys := map[int]string{}
+	for row.Next() {
+		var (
+			id      int
+			videoId string
+		)
+		if err := row.Scan(&id, &videoId); err != nil {
+			log.Fatalln(err)
+		}
+		keys[id] = util.VideoKey(videoId)
+	}
+	row.Close()
+
+	for id, key := range keys {
+		if _, err := db.Exec(`UPDATE queue SET VideoKey = ? WHERE Id = ?`, key, id); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// hasColumn reports whether table has the named column.
+func hasColumn(table, column string) bool {
+	row, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
 		log.Fatalln(err)
+	}
+	defer row.Close()
+
+	for row.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    bool
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := row.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			log.Fatalln(err)
+		}
+		if strings.EqualFold(name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// addColumnIfMissing adds a column to an existing table so databases created
+// by older versions pick up new fields. It reports whether the column was added.
+func addColumnIfMissing(table, column, definition string) bool {
+	if hasColumn(table, column) {
+		return false
+	}
+
+	_, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s" %s`, table, column, definition))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return true
+}
+
+// InsertQueueItem adds an item to the end of the queue.
+func InsertQueueItem(item QueueItem) error {
+	return InsertQueueItems([]QueueItem{item})
+}
+
+// InsertQueueItems adds items to the end of the queue in a single transaction,
+// so either all of them are added or none are. Items without a status are
+// queued, others such as imported history keep theirs.
+func InsertQueueItems(items []QueueItem) error {
+	tx, err := db.Begin()
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	columns := append([]string{"videoId", "videoKey", "outputName", "audioFormat", "extraCommands", "preset", "status", "priority", "notBefore", "rateLimit",
+		"title", "uploader", "duration", "thumbnail", "filePath", "fileSize", "extractor", "webpageUrl", "startedAt", "completedAt", "archivedAt"}, optionColumns()...)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insertNoteSQL := fmt.Sprintf(`INSERT INTO queue(%s, position)
+    VALUES (%s, (SELECT COALESCE(MAX(Position), 0) + 1 FROM queue))`, strings.Join(columns, ", "), placeholders)
+	statement, err := tx.Prepare(insertNoteSQL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer statement.Close()
+
+	for _, item := range items {
+		status := item.Status
+		if len(status) == 0 {
+			status = "queued"
+		}
+
+		values := []interface{}{
+			item.VideoId,
+			util.VideoKey(item.VideoId),
+			item.OutputName,
+			item.AudioFormat,
+			item.ExtraCommands,
+			item.Preset,
+			status,
+			item.Priority,
+			item.NotBefore,
+			item.RateLimit,
+			item.Title,
+			item.Uploader,
+			item.Duration,
+			item.Thumbnail,
+			item.FilePath,
+			item.FileSize,
+			item.Extractor,
+			item.WebpageUrl,
+			item.StartedAt,
+			item.CompletedAt,
+			item.ArchivedAt,
+		}
+		result, err := statement.Exec(append(values, optionValues(item.Options)...)...)
+		if err != nil {
+			return err
+		}
+
+		if len(item.Tags) > 0 {
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			if err := setTags(tx, id, item.Tags); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
 }
 
+// UpdateQueueItemStatus changes the status of an item, recording when a
+// download starts and when it completes or fails. The reason for an earlier
+// failure goes once the item is queued or downloading again.
 func UpdateQueueItemStatus(id int, status string) error {
-	insertNoteSQL := `UPDATE queue SET Status = ? WHERE id = ?`
+	insertNoteSQL := `UPDATE queue SET Status = ?,
+    ErrorMessage = CASE WHEN ? IN ('queued', 'downloading') THEN '' ELSE ErrorMessage END,
+    StartedAt = CASE WHEN ? = 'downloading' THEN ? ELSE StartedAt END,
+    CompletedAt = CASE WHEN ? IN ('completed', 'error') THEN ? WHEN ? = 'downloading' THEN NULL ELSE CompletedAt END
+    WHERE id = ?`
 	statement, err := db.Prepare(insertNoteSQL)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	_, err = statement.Exec(status, id)
+	now := time.Now()
+	_, err = statement.Exec(status, status, status, now, status, now, status, id)
+	if err != nil {
+		log.Fatalln(err)
+		return err
+	}
+
+	return nil
+}
+
+// FailQueueItem marks an item as failed, saying why.
+func FailQueueItem(id int, message string) error {
+	if err := UpdateQueueItemStatus(id, "error"); err != nil {
+		return err
+	}
+	_, err := db.Exec(`UPDATE queue SET ErrorMessage = ? WHERE id = ?`, message, id)
+	return err
+}
+
+// UpdateQueueItemMetadata stores what yt-dlp reported about a finished download.
+func UpdateQueueItemMetadata(id int, metadata Metadata) error {
+	updateSQL := `UPDATE queue SET Title = ?, Uploader = ?, Duration = ?, Thumbnail = ?, FilePath = ?, FileSize = ?, Extractor = ?, WebpageUrl = ? WHERE id = ?`
+	_, err := db.Exec(updateSQL,
+		metadata.Title,
+		metadata.Uploader,
+		metadata.Duration,
+		metadata.Thumbnail,
+		metadata.FilePath,
+		metadata.FileSize,
+		metadata.Extractor,
+		metadata.WebpageUrl,
+		id,
+	)
+	return err
+}
+
+// InsertPresetQueueItem queues a video with the download options of preset.
+func InsertPresetQueueItem(videoId, outputName string, preset util.PresetConfig) error {
+	return InsertQueueItem(QueueItem{
+		VideoId:       videoId,
+		OutputName:    outputName,
+		AudioFormat:   preset.AudioFormat,
+		ExtraCommands: preset.ExtraCommands,
+		Preset:        preset.Name,
+		Options:       presetOptions(ytdlp.Options{}, preset),
+	})
+}
+
+// presetOptions returns options with the ones preset sets applied.
+func presetOptions(options ytdlp.Options, preset util.PresetConfig) ytdlp.Options {
+	options = options.Clone()
+	options.SetBool(ytdlp.EmbedThumbnail, preset.EmbedThumbnail)
+	options.SetBool(ytdlp.AudioOnly, preset.AudioOnly)
+	return options
+}
+
+// RequeueQueueItem puts an item back on the end of the queue, used for retrying
+// failed downloads and downloading completed items again.
+func RequeueQueueItem(id int) error {
+	requeueSQL := `UPDATE queue SET Status = ?, ArchivedAt = NULL, ErrorMessage = '', Position = (SELECT COALESCE(MAX(Position), 0) + 1 FROM queue) WHERE id = ?`
+	statement, err := db.Prepare(requeueSQL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	_, err = statement.Exec("queued", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateQueueItemPreset stores the preset name on an item along with the
+// download options the preset resolves to on top of the item's options. Every
+// option column is written, as InsertQueueItems does.
+func UpdateQueueItemPreset(id int, preset util.PresetConfig, options ytdlp.Options) error {
+	assignments := []string{"Preset = ?", "AudioFormat = ?", "ExtraCommands = ?"}
+	for _, column := range optionColumns() {
+		assignments = append(assignments, column+" = ?")
+	}
+	updatePresetSQL := fmt.Sprintf(`UPDATE queue SET %s WHERE id = ?`, strings.Join(assignments, ", "))
+	statement, err := db.Prepare(updatePresetSQL)
 	if err != nil {
 		log.Fatalln(err)
+	}
+
+	values := append([]interface{}{preset.Name, preset.AudioFormat, preset.ExtraCommands}, optionValues(presetOptions(options, preset))...)
+	_, err = statement.Exec(append(values, id)...)
+	if err != nil {
 		return err
 	}
 
@@ -122,13 +568,65 @@ func DeleteQueueItem(id int) error {
 		return err
 	}
 
+	util.DeleteDownloadLogs(dbPath, id)
 	return nil
 }
 
-func GetAllQueueItems(status string) ([]*QueueItem, error) {
-	row, err := db.Query("SELECT * FROM queue WHERE Status = $1", status)
+// queueItemColumns returns the column list used by every query that scans
+// into a QueueItem, ending with a column for each registered option.
+func queueItemColumns() string {
+	return `Id, VideoId, OutputName, AudioFormat, Status, ExtraCommands, Priority, Position, Preset, VideoKey,
+  Title, Uploader, Duration, Thumbnail, FilePath, FileSize, Extractor, WebpageUrl, StartedAt, CompletedAt, NotBefore, RateLimit,
+  ArchivedAt, ErrorMessage, ` + strings.Join(optionColumns(), ", ")
+}
+
+// queueOrder is the order items are downloaded in: highest priority first, then
+// by their position in the queue.
+const queueOrder = `ORDER BY Priority DESC, Position ASC, Id ASC`
+
+func scanQueueItem(row *sql.Rows) (*QueueItem, error) {
+	var queueItem QueueItem
+
+	options := optionTargets()
+	targets := []interface{}{
+		&queueItem.Id,
+		&queueItem.VideoId,
+		&queueItem.OutputName,
+		&queueItem.AudioFormat,
+		&queueItem.Status,
+		&queueItem.ExtraCommands,
+		&queueItem.Priority,
+		&queueItem.Position,
+		&queueItem.Preset,
+		&queueItem.VideoKey,
+		&queueItem.Title,
+		&queueItem.Uploader,
+		&queueItem.Duration,
+		&queueItem.Thumbnail,
+		&queueItem.FilePath,
+		&queueItem.FileSize,
+		&queueItem.Extractor,
+		&queueItem.WebpageUrl,
+		&queueItem.StartedAt,
+		&queueItem.CompletedAt,
+		&queueItem.NotBefore,
+		&queueItem.RateLimit,
+		&queueItem.ArchivedAt,
+		&queueItem.ErrorMessage,
+	}
+	if err := row.Scan(append(targets, options...)...); err != nil {
+		return &queueItem, err
+	}
+
+	queueItem.Options = scannedOptions(options)
+	return &queueItem, nil
+}
+
+// queryQueueItems runs a query selecting queueItemColumns and scans every row.
+func queryQueueItems(query string, args ...interface{}) ([]*QueueItem, error) {
+	row, err := db.Query(query, args...)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	defer row.Close()
@@ -136,21 +634,202 @@ func GetAllQueueItems(status string) ([]*QueueItem, error) {
 	queueItems := []*QueueItem{}
 
 	for row.Next() {
-		var queueItem QueueItem
-
-		row.Scan(
-			&queueItem.Id,
-			&queueItem.VideoId,
-			&queueItem.OutputName,
-			&queueItem.EmbedThumbnail,
-			&queueItem.AudioOnly,
-			&queueItem.AudioFormat,
-			&queueItem.Status,
-			&queueItem.ExtraCommands,
-		)
+		queueItem, err := scanQueueItem(row)
+		if err != nil {
+			return queueItems, err
+		}
+
+		queueItems = append(queueItems, queueItem)
+	}
+	if err := row.Err(); err != nil {
+		return queueItems, err
+	}
+	row.Close()
+
+	return queueItems, attachTags(queueItems)
+}
+
+// Statuses are the statuses a queue item can be in.
+var Statuses = []string{"queued", "downloading", "completed", "error"}
+
+// GetAllQueueItems returns the items in any of the given statuses in queue order.
+func GetAllQueueItems(statuses ...string) ([]*QueueItem, error) {
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args[i] = status
+	}
+
+	return queryQueueItems("SELECT "+queueItemColumns()+" FROM queue WHERE Status IN ("+strings.Join(placeholders, ", ")+") "+queueOrder, args...)
+}
+
+// recentOrder lists the most recently finished, or for unfinished items
+// started, first.
+const recentOrder = `ORDER BY COALESCE(CompletedAt, StartedAt) IS NULL, COALESCE(CompletedAt, StartedAt) DESC, Id DESC`
+
+// completedCondition selects the completed items that haven't been archived,
+// with tag when it isn't empty, and returns its arguments.
+func completedCondition(tag string) (string, []interface{}) {
+	if len(tag) == 0 {
+		return `Status = 'completed' AND ArchivedAt IS NULL`, nil
+	}
+	return `Status = 'completed' AND ArchivedAt IS NULL AND Id IN (SELECT queue_tags.QueueId FROM queue_tags JOIN tags ON tags.Id = queue_tags.TagId WHERE tags.Name = ?)`, []interface{}{tag}
+}
+
+// GetCompletedQueueItems returns up to limit of the completed items that
+// haven't been archived, skipping the first offset, the most recent first.
+// An empty tag returns items with any tags.
+func GetCompletedQueueItems(tag string, limit, offset int) ([]*QueueItem, error) {
+	condition, args := completedCondition(tag)
+	return queryQueueItems("SELECT "+queueItemColumns()+" FROM queue WHERE "+condition+" "+recentOrder+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+}
+
+// CountCompletedQueueItems returns how many items GetCompletedQueueItems
+// pages through.
+func CountCompletedQueueItems(tag string) (int, error) {
+	condition, args := completedCondition(tag)
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM queue WHERE "+condition, args...).Scan(&count)
+	return count, err
+}
 
-		queueItems = append(queueItems, &queueItem)
+// GetQueueItem returns the item with the given id.
+func GetQueueItem(id int) (*QueueItem, error) {
+	row, err := db.Query("SELECT "+queueItemColumns()+" FROM queue WHERE Id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, fmt.Errorf("queue item %d not found", id)
+	}
+
+	item, err := scanQueueItem(row)
+	if err != nil {
+		return item, err
+	}
+	row.Close()
+
+	return item, attachTags([]*QueueItem{item})
+}
+
+// FindDuplicateQueueItems returns the items that point at the same video as
+// videoId, whatever form of URL they were added with.
+func FindDuplicateQueueItems(videoId string) ([]*QueueItem, error) {
+	return queryQueueItems("SELECT "+queueItemColumns()+" FROM queue WHERE VideoKey = ? "+queueOrder, util.VideoKey(videoId))
+}
+
+// GetVideoKeyStatuses returns the status of every video in the queue and
+// history by video key, taking the first in queue order when a video was added
+// more than once.
+func GetVideoKeyStatuses() (map[string]string, error) {
+	row, err := db.Query(`SELECT VideoKey, Status FROM queue ` + queueOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	statuses := map[string]string{}
+	for row.Next() {
+		var key, status string
+		if err := row.Scan(&key, &status); err != nil {
+			return statuses, err
+		}
+		if _, ok := statuses[key]; !ok {
+			statuses[key] = status
+		}
+	}
+	return statuses, row.Err()
+}
+
+// GetNextQueueItem returns the queued item that should be downloaded next at
+// now, skipping items that aren't allowed to start yet. It returns nil when
+// nothing can start.
+func GetNextQueueItem(now time.Time) (*QueueItem, error) {
+	queueItems, err := GetAllQueueItems("queued")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, queueItem := range queueItems {
+		if queueItem.NotBefore.Valid && queueItem.NotBefore.Time.After(now) {
+			continue
+		}
+		return queueItem, nil
+	}
+
+	return nil, nil
+}
+
+// MoveQueueItem moves a queued item by offset places, negative offsets moving it
+// towards the front of the queue.
+func MoveQueueItem(id, offset int) error {
+	return moveQueueItem(id, func(index int) int { return index + offset })
+}
+
+// MoveQueueItemToTop moves a queued item to the front of the queue.
+func MoveQueueItemToTop(id int) error {
+	return moveQueueItem(id, func(int) int { return 0 })
+}
+
+// moveQueueItem reorders the queued items so that id ends up at the index
+// returned by target. The moved item takes the priority of the item it jumped
+// over so that the priority ordering still holds, and positions are rewritten
+// to match the new order.
+func moveQueueItem(id int, target func(index int) int) error {
+	queueItems, err := GetAllQueueItems("queued")
+	if err != nil {
+		return err
+	}
+
+	from := -1
+	for i, item := range queueItems {
+		if item.Id == id {
+			from = i
+			break
+		}
+	}
+	if from == -1 {
+		return fmt.Errorf("queue item %d is not queued", id)
+	}
+
+	to := target(from)
+	if to < 0 {
+		to = 0
+	}
+	if to > len(queueItems)-1 {
+		to = len(queueItems) - 1
+	}
+	if to == from {
+		return nil
+	}
+
+	moved := queueItems[from]
+	moved.Priority = queueItems[to].Priority
+	queueItems = append(queueItems[:from], queueItems[from+1:]...)
+	queueItems = append(queueItems[:to], append([]*QueueItem{moved}, queueItems[to:]...)...)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	statement, err := tx.Prepare(`UPDATE queue SET Priority = ?, Position = ? WHERE id = ?`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer statement.Close()
+
+	for i, item := range queueItems {
+		if _, err := statement.Exec(item.Priority, i+1, item.Id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
 	}
 
-	return queueItems, nil
+	return tx.Commit()
 }
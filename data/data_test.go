@@ -0,0 +1,82 @@
+package data
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// openDatabase opens an empty database for the test, with the data directory
+// the download logs go in inside the test's temporary directory.
+func openDatabase(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	if err := Open(util.SettingsConfig{DatabasePath: filepath.Join(dir, "test.db")}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = CloseDatabase() })
+}
+
+func TestOptionColumnsRoundTrip(t *testing.T) {
+	openDatabase(t)
+
+	tests := []ytdlp.Options{
+		{},
+		{ytdlp.AudioOnly: "true", ytdlp.EmbedThumbnail: "true"},
+		{"subtitle_langs": "en,fr", "merge_output_format": "mkv", "concurrent_fragments": "4"},
+		{"download_sections": "1:30-2:45,Intro.*", "sponsorblock": "all,-filler", "embed_metadata": "true"},
+	}
+	items := []QueueItem{}
+	for _, options := range tests {
+		items = append(items, QueueItem{VideoId: "https://example.com/video", Options: options})
+	}
+	if err := InsertQueueItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	queued, err := GetAllQueueItems("queued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range queued {
+		if !reflect.DeepEqual(item.Options, tests[i]) {
+			t.Errorf("item %d has options %v, want %v", i, item.Options, tests[i])
+		}
+	}
+}
+
+func TestUpdateQueueItemPreset(t *testing.T) {
+	openDatabase(t)
+
+	options := ytdlp.Options{ytdlp.EmbedThumbnail: "true", "subtitle_langs": "en", "concurrent_fragments": "4"}
+	if err := InsertQueueItem(QueueItem{VideoId: "https://example.com/video", AudioFormat: "wav", Preset: "video", Options: options}); err != nil {
+		t.Fatal(err)
+	}
+	items, err := GetAllQueueItems("queued")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preset := util.PresetConfig{Name: "audio", AudioOnly: true, AudioFormat: "mp3", ExtraCommands: "--no-mtime"}
+	if err := UpdateQueueItemPreset(items[0].Id, preset, items[0].Options); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := GetQueueItem(items[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Preset != "audio" || item.AudioFormat != "mp3" || item.ExtraCommands != "--no-mtime" {
+		t.Errorf("item is %+v, want the audio preset", item)
+	}
+	// the preset's options replace the item's, the rest are kept
+	want := ytdlp.Options{ytdlp.AudioOnly: "true", "subtitle_langs": "en", "concurrent_fragments": "4"}
+	if !reflect.DeepEqual(item.Options, want) {
+		t.Errorf("item has options %v, want %v", item.Options, want)
+	}
+}
@@ -0,0 +1,121 @@
+package data
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Feed is an RSS or Atom feed added from the command line. Feeds can also be
+// listed in the config file, those aren't stored here.
+type Feed struct {
+	Id         int
+	Url        string
+	Preset     string
+	TitleRegex string
+	DateAfter  string
+	Enclosures bool
+}
+
+func CreateFeedTables() {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS feeds (
+		"Id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"Url" TEXT NOT NULL UNIQUE,
+		"Preset" TEXT NOT NULL DEFAULT '',
+		"TitleRegex" TEXT NOT NULL DEFAULT '',
+		"DateAfter" TEXT NOT NULL DEFAULT '',
+		"Enclosures" BOOL NOT NULL DEFAULT 0
+	  );`,
+		`CREATE TABLE IF NOT EXISTS feed_checks (
+		"Url" TEXT NOT NULL PRIMARY KEY,
+		"LastChecked" DATETIME NOT NULL
+	  );`,
+		`CREATE TABLE IF NOT EXISTS feed_entries (
+		"FeedUrl" TEXT NOT NULL,
+		"EntryId" TEXT NOT NULL,
+		PRIMARY KEY ("FeedUrl", "EntryId")
+	  );`,
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func InsertFeed(feed Feed) (int, error) {
+	insertSQL := `INSERT INTO feeds(url, preset, titleRegex, dateAfter, enclosures) VALUES (?, ?, ?, ?, ?)`
+	result, err := db.Exec(insertSQL, feed.Url, feed.Preset, feed.TitleRegex, feed.DateAfter, feed.Enclosures)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func DeleteFeed(id int) error {
+	_, err := db.Exec(`DELETE FROM feeds WHERE Id = ?`, id)
+	return err
+}
+
+func GetAllFeeds() ([]*Feed, error) {
+	row, err := db.Query(`SELECT Id, Url, Preset, TitleRegex, DateAfter, Enclosures FROM feeds ORDER BY Id`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer row.Close()
+
+	feeds := []*Feed{}
+
+	for row.Next() {
+		var feed Feed
+
+		err := row.Scan(
+			&feed.Id,
+			&feed.Url,
+			&feed.Preset,
+			&feed.TitleRegex,
+			&feed.DateAfter,
+			&feed.Enclosures,
+		)
+		if err != nil {
+			return feeds, err
+		}
+
+		feeds = append(feeds, &feed)
+	}
+
+	return feeds, row.Err()
+}
+
+// GetFeedLastChecked returns when the feed at url was last checked.
+func GetFeedLastChecked(url string) (sql.NullTime, error) {
+	var lastChecked sql.NullTime
+	err := db.QueryRow(`SELECT LastChecked FROM feed_checks WHERE Url = ?`, url).Scan(&lastChecked)
+	if err == sql.ErrNoRows {
+		return lastChecked, nil
+	}
+	return lastChecked, err
+}
+
+func UpdateFeedLastChecked(url string, checked time.Time) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO feed_checks(Url, LastChecked) VALUES (?, ?)`, url, checked)
+	return err
+}
+
+// IsFeedEntrySeen reports whether an entry has already been seen on a feed,
+// whether or not it was queued.
+func IsFeedEntrySeen(feedUrl, entryId string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM feed_entries WHERE FeedUrl = ? AND EntryId = ?`, feedUrl, entryId).Scan(&count)
+	return count > 0, err
+}
+
+func MarkFeedEntrySeen(feedUrl, entryId string) error {
+	_, err := db.Exec(`INSERT OR IGNORE INTO feed_entries(FeedUrl, EntryId) VALUES (?, ?)`, feedUrl, entryId)
+	return err
+}
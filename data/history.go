@@ -0,0 +1,163 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// historySearchColumns are the queue columns the full text index covers, in
+// the order of the index's own columns. The index has a Tags column after them.
+var historySearchColumns = []string{"Title", "Uploader", "VideoId", "OutputName"}
+
+// historySearchTriggers keep the index up to date as items and their tags change.
+var historySearchTriggers = []string{"history_search_insert", "history_search_update", "history_search_delete", "history_search_tag_insert", "history_search_tag_delete"}
+
+// historySearchRow returns the statement that indexes the queue items matching
+// where, with their tags.
+func historySearchRow(where string) string {
+	columns := strings.Join(historySearchColumns, ", ")
+	return fmt.Sprintf(`INSERT INTO history_search(docid, %s, Tags)
+      SELECT Id, %s, (SELECT GROUP_CONCAT(tags.Name, ' ') FROM queue_tags JOIN tags ON tags.Id = queue_tags.TagId WHERE queue_tags.QueueId = queue.Id)
+      FROM queue WHERE %s`, columns, columns, where)
+}
+
+// CreateHistoryTables creates the full text index over the queue and the
+// triggers that keep it up to date. The index is filled from the queue when it
+// is created, and rebuilt when it is from a version that didn't index tags.
+func CreateHistoryTables() {
+	var existing int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'history_search'`).Scan(&existing); err != nil {
+		log.Fatalln(err)
+	}
+	if existing > 0 && !hasColumn("history_search", "Tags") {
+		statements := []string{`DROP TABLE history_search`}
+		for _, trigger := range historySearchTriggers {
+			statements = append(statements, "DROP TRIGGER IF EXISTS "+trigger)
+		}
+		for _, statement := range statements {
+			if _, err := db.Exec(statement); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		existing = 0
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS history_search USING fts4(%s, Tags, tokenize=unicode61)`, strings.Join(historySearchColumns, ", ")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS history_search_insert AFTER INSERT ON queue BEGIN
+      %s;
+    END`, historySearchRow("Id = new.Id")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS history_search_update AFTER UPDATE OF %s ON queue BEGIN
+      DELETE FROM history_search WHERE docid = old.Id;
+      %s;
+    END`, strings.Join(historySearchColumns, ", "), historySearchRow("Id = new.Id")),
+		`CREATE TRIGGER IF NOT EXISTS history_search_delete AFTER DELETE ON queue BEGIN
+      DELETE FROM history_search WHERE docid = old.Id;
+    END`,
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS history_search_tag_insert AFTER INSERT ON queue_tags BEGIN
+      DELETE FROM history_search WHERE docid = new.QueueId;
+      %s;
+    END`, historySearchRow("Id = new.QueueId")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS history_search_tag_delete AFTER DELETE ON queue_tags BEGIN
+      DELETE FROM history_search WHERE docid = old.QueueId;
+      %s;
+    END`, historySearchRow("Id = old.QueueId")),
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if existing == 0 {
+		if _, err := db.Exec(historySearchRow("1")); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// Media types a history search can be limited to.
+const (
+	AudioMedia = "audio"
+	VideoMedia = "video"
+)
+
+// HistoryFilter narrows a history search. Empty fields don't filter.
+type HistoryFilter struct {
+	// Text is matched against the title, uploader, URL, output name and tags,
+	// every word has to appear at the start of a word in one of them.
+	Text     string
+	Statuses []string
+	Preset   string
+	// Media is AudioMedia for audio only downloads or VideoMedia for the rest.
+	Media string
+	// From and To limit the downloads to those finished, or for unfinished
+	// ones started, from the start of From until the end of To.
+	From, To time.Time
+	Limit    int
+}
+
+// searchQuery turns text into a full text query matching every word as a
+// prefix. Anything that isn't a letter or digit separates words, so the
+// query syntax can't be misused.
+func searchQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// SearchHistory returns the items matching filter, the most recent first.
+func SearchHistory(filter HistoryFilter) ([]*QueueItem, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if query := searchQuery(filter.Text); len(query) > 0 {
+		conditions = append(conditions, `Id IN (SELECT docid FROM history_search WHERE history_search MATCH ?)`)
+		args = append(args, query)
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = "?"
+			args = append(args, status)
+		}
+		conditions = append(conditions, "Status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if len(filter.Preset) > 0 {
+		conditions = append(conditions, "Preset = ?")
+		args = append(args, filter.Preset)
+	}
+	switch filter.Media {
+	case AudioMedia:
+		conditions = append(conditions, "AudioOnly")
+	case VideoMedia:
+		conditions = append(conditions, "NOT AudioOnly")
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "COALESCE(CompletedAt, StartedAt) >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "COALESCE(CompletedAt, StartedAt) < ?")
+		args = append(args, filter.To.AddDate(0, 0, 1))
+	}
+
+	query := "SELECT " + queueItemColumns() + " FROM queue"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " " + recentOrder
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	return queryQueueItems(query, args...)
+}
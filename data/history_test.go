@@ -0,0 +1,89 @@
+package data
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "", want: ""},
+		{text: "   ", want: ""},
+		{text: "jazz", want: "jazz*"},
+		{text: "Live  jazz", want: "Live* jazz*"},
+		{text: "café crème", want: "café* crème*"},
+		{text: "youtube.com/watch?v=abc123", want: "youtube* com* watch* v* abc123*"},
+		// the full text query syntax is taken apart rather than passed on
+		{text: `"jazz" OR -blues*`, want: "jazz* OR* blues*"},
+		{text: "title:jazz NEAR/2 (live)", want: "title* jazz* NEAR* 2* live*"},
+	}
+
+	for _, test := range tests {
+		if got := searchQuery(test.text); got != test.want {
+			t.Errorf("searchQuery(%q) is %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSearchHistory(t *testing.T) {
+	openDatabase(t)
+
+	day := func(d int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2024, 3, d, 12, 0, 0, 0, time.Local), Valid: true}
+	}
+	audio := ytdlp.Options{ytdlp.AudioOnly: "true"}
+	items := []QueueItem{
+		{VideoId: "https://example.com/1", Title: "Late night jazz", Uploader: "Café Records", Status: "completed", Preset: "audio", Options: audio, CompletedAt: day(1), Tags: []string{"Music"}},
+		{VideoId: "https://example.com/2", Title: "Jazz guitar lesson", Uploader: "Teacher", Status: "completed", CompletedAt: day(5), Tags: []string{"Lessons"}},
+		{VideoId: "https://example.com/3", Title: "Blues jam", Uploader: "Cafe Records", Status: "error", StartedAt: day(8)},
+		{VideoId: "https://example.com/4", OutputName: "conference keynote", Status: "queued"},
+	}
+	if err := InsertQueueItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   string
+	}{
+		{name: "everything", filter: HistoryFilter{}, want: "3,2,1,4"},
+		{name: "word", filter: HistoryFilter{Text: "jazz"}, want: "2,1"},
+		{name: "word prefixes", filter: HistoryFilter{Text: "ja gui"}, want: "2"},
+		{name: "accents and case", filter: HistoryFilter{Text: "CAFE"}, want: "3,1"},
+		{name: "output name", filter: HistoryFilter{Text: "keynote"}, want: "4"},
+		{name: "tags", filter: HistoryFilter{Text: "music"}, want: "1"},
+		{name: "statuses", filter: HistoryFilter{Statuses: []string{"error", "queued"}}, want: "3,4"},
+		{name: "preset", filter: HistoryFilter{Preset: "audio"}, want: "1"},
+		{name: "audio", filter: HistoryFilter{Media: AudioMedia}, want: "1"},
+		{name: "video", filter: HistoryFilter{Media: VideoMedia, Statuses: []string{"completed"}}, want: "2"},
+		{name: "date range", filter: HistoryFilter{From: day(2).Time, To: day(5).Time}, want: "2"},
+		{name: "from a date", filter: HistoryFilter{From: day(5).Time}, want: "3,2"},
+		{name: "limit", filter: HistoryFilter{Limit: 2}, want: "3,2"},
+		// OR is a word to match rather than an operator
+		{name: "query syntax", filter: HistoryFilter{Text: `jazz" OR "blues`}, want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := SearchHistory(test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, item := range found {
+				ids = append(ids, strings.TrimPrefix(item.VideoId, "https://example.com/"))
+			}
+			if got := strings.Join(ids, ","); got != test.want {
+				t.Errorf("found %s, want %s", got, test.want)
+			}
+		})
+	}
+}
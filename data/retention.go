@@ -0,0 +1,117 @@
+package data
+
+import (
+	"strings"
+	"time"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// Retention is how much of the Done list is kept. Zero limits keep
+// everything. With both limits set an item is kept while it meets either of
+// them, so the Done list always holds at least the Items most recent
+// downloads and everything from the last Days days.
+type Retention struct {
+	// Items keeps the most recently completed downloads.
+	Items int
+	// Days keeps the downloads completed in the last Days days.
+	Days int
+	// Archive takes older downloads off the Done list but keeps them in the
+	// history, instead of deleting them.
+	Archive bool
+}
+
+// RetentionFromSettings returns the retention policy of settings.
+func RetentionFromSettings(settings util.SettingsConfig) Retention {
+	return Retention{
+		Items:   settings.HistoryKeepItems,
+		Days:    settings.HistoryKeepDays,
+		Archive: settings.HistoryArchive,
+	}
+}
+
+// ApplyRetention archives or deletes the completed items that retention
+// doesn't keep, those outside every limit that is set, and returns how many
+// there were.
+func ApplyRetention(retention Retention, now time.Time) (int, error) {
+	if retention.Items <= 0 && retention.Days <= 0 {
+		return 0, nil
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if retention.Items > 0 {
+		conditions = append(conditions, `Id NOT IN (SELECT Id FROM queue WHERE Status = 'completed' AND ArchivedAt IS NULL `+recentOrder+` LIMIT ?)`)
+		args = append(args, retention.Items)
+	}
+	if retention.Days > 0 {
+		conditions = append(conditions, `COALESCE(CompletedAt, StartedAt) < ?`)
+		args = append(args, now.AddDate(0, 0, -retention.Days))
+	}
+
+	ids, err := completedIds(strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), retire(ids, retention.Archive, now)
+}
+
+// ClearHistory archives or deletes every completed item on the Done list, and
+// returns how many there were.
+func ClearHistory(archive bool, now time.Time) (int, error) {
+	ids, err := completedIds("1")
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), retire(ids, archive, now)
+}
+
+// completedIds returns the ids of the completed items that haven't been
+// archived and match condition.
+func completedIds(condition string, args ...interface{}) ([]int, error) {
+	row, err := db.Query(`SELECT Id FROM queue WHERE Status = 'completed' AND ArchivedAt IS NULL AND (`+condition+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	ids := []int{}
+	for row.Next() {
+		var id int
+		if err := row.Scan(&id); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, row.Err()
+}
+
+// retire archives the items with ids, or deletes them along with their logs.
+func retire(ids []int, archive bool, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if archive {
+			_, err = tx.Exec(`UPDATE queue SET ArchivedAt = ? WHERE Id = ?`, now, id)
+		} else {
+			_, err = tx.Exec(`DELETE FROM queue WHERE Id = ?`, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if !archive {
+		for _, id := range ids {
+			util.DeleteDownloadLogs(dbPath, id)
+		}
+	}
+	return nil
+}
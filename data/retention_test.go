@@ -0,0 +1,194 @@
+package data
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// completeItems adds an item, with a log, completed each of the durations
+// given before now, named after it, and returns the ids by name. A queued item
+// is added too, which retention must leave alone.
+func completeItems(t *testing.T, now time.Time, names ...string) map[string]int {
+	t.Helper()
+	items := []QueueItem{{VideoId: "https://example.com/queued", OutputName: "queued"}}
+	for _, name := range names {
+		age, err := time.ParseDuration(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, QueueItem{
+			VideoId:     "https://example.com/" + name,
+			OutputName:  name,
+			Status:      "completed",
+			CompletedAt: sql.NullTime{Time: now.Add(-age), Valid: true},
+		})
+	}
+	if err := InsertQueueItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]int{}
+	for _, status := range []string{"queued", "completed"} {
+		inserted, err := GetAllQueueItems(status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range inserted {
+			ids[item.OutputName] = item.Id
+			log, err := util.CreateDownloadLog(Path(), item.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			log.Close()
+		}
+	}
+	return ids
+}
+
+// doneList returns the names of the items on the Done list, the most recent
+// first.
+func doneList(t *testing.T) string {
+	t.Helper()
+	items, err := GetCompletedQueueItems("", 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.OutputName)
+	}
+	return strings.Join(names, ",")
+}
+
+func hasLog(t *testing.T, id int) bool {
+	t.Helper()
+	path, err := util.DownloadLogPath(Path(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func TestApplyRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention Retention
+		kept      string
+	}{
+		{name: "no limits", retention: Retention{}, kept: "24h,72h,240h,480h,960h"},
+		{name: "items only", retention: Retention{Items: 2}, kept: "24h,72h"},
+		{name: "days only", retention: Retention{Days: 15}, kept: "24h,72h,240h"},
+		// an item is kept while it is within either limit
+		{name: "more items than days", retention: Retention{Items: 4, Days: 15}, kept: "24h,72h,240h,480h"},
+		{name: "more days than items", retention: Retention{Items: 1, Days: 5}, kept: "24h,72h"},
+		{name: "limits beyond the list", retention: Retention{Items: 10, Days: 100}, kept: "24h,72h,240h,480h,960h"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			openDatabase(t)
+			now := time.Now()
+			ids := completeItems(t, now, "24h", "72h", "240h", "480h", "960h")
+
+			retired, err := ApplyRetention(test.retention, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := doneList(t); kept != test.kept {
+				t.Errorf("kept %s, want %s", kept, test.kept)
+			}
+			if want := 5 - len(strings.Split(test.kept, ",")); retired != want {
+				t.Errorf("retired %d items, want %d", retired, want)
+			}
+			if _, err := GetQueueItem(ids["queued"]); err != nil {
+				t.Errorf("the queued item was removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyRetentionArchives(t *testing.T) {
+	openDatabase(t)
+	now := time.Now()
+	ids := completeItems(t, now, "24h", "72h", "240h")
+
+	retired, err := ApplyRetention(Retention{Items: 1, Archive: true}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retired != 2 {
+		t.Errorf("archived %d items, want 2", retired)
+	}
+	if kept := doneList(t); kept != "24h" {
+		t.Errorf("kept %s, want 24h", kept)
+	}
+
+	for _, name := range []string{"72h", "240h"} {
+		item, err := GetQueueItem(ids[name])
+		if err != nil {
+			t.Fatalf("the archived item %s was deleted: %v", name, err)
+		}
+		if !item.ArchivedAt.Valid || item.Status != "completed" {
+			t.Errorf("the item %s wasn't archived: %+v", name, item)
+		}
+		if !hasLog(t, ids[name]) {
+			t.Errorf("the log of the archived item %s was deleted", name)
+		}
+	}
+
+	// archived items don't count towards the limit again
+	if retired, err := ApplyRetention(Retention{Items: 1, Archive: true}, now); err != nil || retired != 0 {
+		t.Errorf("applying the same limit again retired %d items (%v), want 0", retired, err)
+	}
+}
+
+func TestApplyRetentionDeletes(t *testing.T) {
+	openDatabase(t)
+	now := time.Now()
+	ids := completeItems(t, now, "24h", "72h", "240h")
+
+	retired, err := ApplyRetention(Retention{Days: 2}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retired != 2 {
+		t.Errorf("deleted %d items, want 2", retired)
+	}
+
+	for _, name := range []string{"72h", "240h"} {
+		if _, err := GetQueueItem(ids[name]); err == nil {
+			t.Errorf("the item %s wasn't deleted", name)
+		}
+		if hasLog(t, ids[name]) {
+			t.Errorf("the log of the deleted item %s was kept", name)
+		}
+	}
+	for _, name := range []string{"24h", "queued"} {
+		if !hasLog(t, ids[name]) {
+			t.Errorf("the log of the kept item %s was deleted", name)
+		}
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	openDatabase(t)
+	now := time.Now()
+	ids := completeItems(t, now, "24h", "72h")
+
+	cleared, err := ClearHistory(true, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 2 || doneList(t) != "" {
+		t.Errorf("cleared %d items leaving %q, want 2 leaving none", cleared, doneList(t))
+	}
+	if _, err := GetQueueItem(ids["queued"]); err != nil {
+		t.Errorf("the queued item was removed: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Subscription is a channel or playlist that gets polled for new videos.
+type Subscription struct {
+	Id          int
+	Url         string
+	Preset      string
+	TitleRegex  string
+	MinDuration int
+	DateAfter   string
+	LastChecked sql.NullTime
+}
+
+func CreateSubscriptionTables() {
+	createTableSQL := `CREATE TABLE IF NOT EXISTS subscriptions (
+		"Id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"Url" TEXT NOT NULL UNIQUE,
+		"Preset" TEXT NOT NULL DEFAULT '',
+		"TitleRegex" TEXT NOT NULL DEFAULT '',
+		"MinDuration" INTEGER NOT NULL DEFAULT 0,
+		"DateAfter" TEXT NOT NULL DEFAULT '',
+		"LastChecked" DATETIME
+	  );`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		log.Fatalln(err)
+	}
+
+	createEntriesSQL := `CREATE TABLE IF NOT EXISTS subscription_entries (
+		"SubscriptionId" INTEGER NOT NULL REFERENCES subscriptions(Id) ON DELETE CASCADE,
+		"VideoKey" TEXT NOT NULL,
+		PRIMARY KEY ("SubscriptionId", "VideoKey")
+	  );`
+
+	if _, err := db.Exec(createEntriesSQL); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func InsertSubscription(subscription Subscription) (int, error) {
+	insertSQL := `INSERT INTO subscriptions(url, preset, titleRegex, minDuration, dateAfter) VALUES (?, ?, ?, ?, ?)`
+	result, err := db.Exec(insertSQL, subscription.Url, subscription.Preset, subscription.TitleRegex, subscription.MinDuration, subscription.DateAfter)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func DeleteSubscription(id int) error {
+	if _, err := db.Exec(`DELETE FROM subscription_entries WHERE SubscriptionId = ?`, id); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`DELETE FROM subscriptions WHERE Id = ?`, id)
+	return err
+}
+
+func GetAllSubscriptions() ([]*Subscription, error) {
+	row, err := db.Query(`SELECT Id, Url, Preset, TitleRegex, MinDuration, DateAfter, LastChecked FROM subscriptions ORDER BY Id`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer row.Close()
+
+	subscriptions := []*Subscription{}
+
+	for row.Next() {
+		var subscription Subscription
+
+		err := row.Scan(
+			&subscription.Id,
+			&subscription.Url,
+			&subscription.Preset,
+			&subscription.TitleRegex,
+			&subscription.MinDuration,
+			&subscription.DateAfter,
+			&subscription.LastChecked,
+		)
+		if err != nil {
+			return subscriptions, err
+		}
+
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	return subscriptions, row.Err()
+}
+
+func UpdateSubscriptionLastChecked(id int, checked time.Time) error {
+	_, err := db.Exec(`UPDATE subscriptions SET LastChecked = ? WHERE Id = ?`, checked, id)
+	return err
+}
+
+// IsSubscriptionEntrySeen reports whether a video has already been seen on a
+// subscription, whether or not it was queued.
+func IsSubscriptionEntrySeen(subscriptionId int, videoKey string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM subscription_entries WHERE SubscriptionId = ? AND VideoKey = ?`, subscriptionId, videoKey).Scan(&count)
+	return count > 0, err
+}
+
+func MarkSubscriptionEntrySeen(subscriptionId int, videoKey string) error {
+	_, err := db.Exec(`INSERT OR IGNORE INTO subscription_entries(SubscriptionId, VideoKey) VALUES (?, ?)`, subscriptionId, videoKey)
+	return err
+}
@@ -0,0 +1,168 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// CreateTagTables creates the tags and their links to queue items. Links go
+// when their item is deleted.
+func CreateTagTables() {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS tags (
+		"Id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"Name" TEXT NOT NULL UNIQUE COLLATE NOCASE
+	  )`,
+		`CREATE TABLE IF NOT EXISTS queue_tags (
+		"QueueId" INTEGER NOT NULL,
+		"TagId" INTEGER NOT NULL,
+		"Position" INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY ("QueueId", "TagId")
+	  )`,
+		`CREATE TRIGGER IF NOT EXISTS queue_tags_delete AFTER DELETE ON queue BEGIN
+      DELETE FROM queue_tags WHERE QueueId = old.Id;
+    END`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// ParseTags reads a comma separated list of tags. Tags become folder names so
+// they have to be valid file names, a leading # is dropped and repeats, in any
+// case, are left out.
+func ParseTags(value string) ([]string, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if len(tag) == 0 || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if err := util.ValidateFileName(tag); err != nil {
+			return nil, fmt.Errorf("tag %q: %v", tag, err)
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// execer is satisfied by both the database and transactions.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// setTags replaces the tags of the item with id, creating tags that don't
+// exist yet. A tag that exists in another case keeps its first spelling.
+func setTags(tx execer, id int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM queue_tags WHERE QueueId = ?`, id); err != nil {
+		return err
+	}
+	for i, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO tags(Name) VALUES (?)`, tag); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO queue_tags(QueueId, TagId, Position) SELECT ?, Id, ? FROM tags WHERE Name = ?`, id, i, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetQueueItemTags replaces the tags of the item with id.
+func SetQueueItemTags(id int, tags []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setTags(tx, int64(id), tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAllTags returns the tags that are on at least one item, by name.
+func GetAllTags() ([]string, error) {
+	row, err := db.Query(`SELECT Name FROM tags WHERE Id IN (SELECT TagId FROM queue_tags) ORDER BY Name`)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	tags := []string{}
+	for row.Next() {
+		var tag string
+		if err := row.Scan(&tag); err != nil {
+			return tags, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, row.Err()
+}
+
+// attachTags fills in the tags of items, in the order they were given.
+func attachTags(items []*QueueItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byId := map[int]*QueueItem{}
+	for _, item := range items {
+		byId[item.Id] = item
+	}
+
+	// only the tags of these items are read, in batches that stay under
+	// SQLite's limit on query parameters
+	for start := 0; start < len(items); start += tagBatchSize {
+		end := start + tagBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		if err := attachTagBatch(items[start:end], byId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagBatchSize is how many items attachTags reads the tags of at once.
+const tagBatchSize = 500
+
+func attachTagBatch(items []*QueueItem, byId map[int]*QueueItem) error {
+	placeholders := make([]string, len(items))
+	args := make([]interface{}, len(items))
+	for i, item := range items {
+		placeholders[i] = "?"
+		args[i] = item.Id
+	}
+
+	row, err := db.Query(`SELECT queue_tags.QueueId, tags.Name FROM queue_tags JOIN tags ON tags.Id = queue_tags.TagId
+    WHERE queue_tags.QueueId IN (`+strings.Join(placeholders, ", ")+`) ORDER BY queue_tags.QueueId, queue_tags.Position`, args...)
+	if err != nil {
+		return err
+	}
+	defer row.Close()
+
+	for row.Next() {
+		var (
+			id  int
+			tag string
+		)
+		if err := row.Scan(&id, &tag); err != nil {
+			return err
+		}
+		if item, ok := byId[id]; ok {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+	return row.Err()
+}
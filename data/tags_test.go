@@ -0,0 +1,92 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+		err   bool
+	}{
+		{value: "", want: []string{}},
+		{value: " , ,", want: []string{}},
+		{value: "music", want: []string{"music"}},
+		{value: "music, talks ,live sets", want: []string{"music", "talks", "live sets"}},
+		{value: "#music,# talks", want: []string{"music", "talks"}},
+		// repeats in any case keep the first spelling
+		{value: "Music,music,MUSIC,talks", want: []string{"Music", "talks"}},
+		{value: "2024.03", want: []string{"2024.03"}},
+		{value: "music,a/b", err: true},
+		{value: "what?", err: true},
+		{value: "..", err: true},
+		{value: "notes.", err: true},
+		{value: "con", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			tags, err := ParseTags(test.value)
+			if test.err {
+				if err == nil {
+					t.Errorf("parsed %q, want an error", tags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(tags, "|") != strings.Join(test.want, "|") || len(tags) != len(test.want) {
+				t.Errorf("parsed %q, want %q", tags, test.want)
+			}
+		})
+	}
+}
+
+func TestTagsAreReadForEveryItem(t *testing.T) {
+	openDatabase(t)
+
+	// more items than a batch of tags is read for
+	items := []QueueItem{}
+	for i := 0; i < tagBatchSize+20; i++ {
+		items = append(items, QueueItem{VideoId: fmt.Sprintf("https://example.com/%d", i), Tags: []string{fmt.Sprintf("tag %d", i%3), "All"}})
+	}
+	if err := InsertQueueItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	queued, err := GetAllQueueItems("queued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != len(items) {
+		t.Fatalf("read %d items, want %d", len(queued), len(items))
+	}
+	for i, item := range queued {
+		if want := fmt.Sprintf("tag %d,All", i%3); strings.Join(item.Tags, ",") != want {
+			t.Fatalf("item %d has tags %q, want %s", i, item.Tags, want)
+		}
+	}
+
+	// a tag in another case is the same tag
+	if err := SetQueueItemTags(queued[0].Id, []string{"all", "new"}); err != nil {
+		t.Fatal(err)
+	}
+	item, err := GetQueueItem(queued[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(item.Tags, ",") != "All,new" {
+		t.Errorf("item has tags %q, want All,new", item.Tags)
+	}
+	tags, err := GetAllTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tags, ",") != "All,new,tag 0,tag 1,tag 2" {
+		t.Errorf("all tags are %q", tags)
+	}
+}
@@ -0,0 +1,89 @@
+// Package diskspace reports how much room is left on the disk downloads are
+// saved to, and recognises downloads that failed because it ran out.
+package diskspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by Free on systems it can't check.
+var ErrUnsupported = errors.New("checking free disk space isn't supported on this system")
+
+// sizeUnits are the suffixes ParseSize accepts, largest first.
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+// ParseSize reads a size in bytes with an optional K, M, G or T suffix, such
+// as 500M or 2G. An empty value is 0.
+func ParseSize(value string) (int64, error) {
+	original := value
+	value = strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(value), "B"))
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.size
+			value = strings.TrimSuffix(value, unit.suffix)
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a number of bytes with an optional K, M, G or T suffix", original)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// Free returns the bytes available to telecharger on the disk path is on.
+// Folders that don't exist yet are checked on the nearest one that does.
+func Free(path string) (int64, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return free(path)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, fmt.Errorf("no folder of %s exists", path)
+		}
+		path = parent
+	}
+}
+
+// noSpaceMessages are how yt-dlp and the systems it runs on report a full disk.
+var noSpaceMessages = []string{
+	"no space left on device",
+	"errno 28",
+	"enospc",
+	"not enough space on the disk",
+}
+
+// IsNoSpace reports whether a line of yt-dlp output says the disk is full.
+func IsNoSpace(line string) bool {
+	line = strings.ToLower(line)
+	for _, message := range noSpaceMessages {
+		if strings.Contains(line, message) {
+			return true
+		}
+	}
+	return false
+}
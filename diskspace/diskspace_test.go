@@ -0,0 +1,90 @@
+package diskspace
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		err   bool
+	}{
+		{value: "", want: 0},
+		{value: "0", want: 0},
+		{value: "4096", want: 4096},
+		{value: "500M", want: 500 << 20},
+		{value: "500m", want: 500 << 20},
+		{value: "1.5G", want: 3 << 29},
+		{value: " 2GB ", want: 2 << 30},
+		{value: "1T", want: 1 << 40},
+		{value: "10K", want: 10 << 10},
+		{value: "lots", err: true},
+		{value: "-1G", err: true},
+		{value: "5P", err: true},
+		{value: "G", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			size, err := ParseSize(test.value)
+			if test.err {
+				if err == nil {
+					t.Errorf("parsed %d, want an error", size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != test.want {
+				t.Errorf("parsed %d, want %d", size, test.want)
+			}
+		})
+	}
+}
+
+func TestFree(t *testing.T) {
+	dir := t.TempDir()
+	free, err := Free(dir)
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free <= 0 {
+		t.Errorf("%s has %d bytes free", dir, free)
+	}
+
+	// a folder that doesn't exist yet is checked on the nearest one that does
+	missing, err := Free(filepath.Join(dir, "not", "yet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing <= 0 {
+		t.Errorf("a missing folder has %d bytes free", missing)
+	}
+}
+
+func TestIsNoSpace(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "ERROR: unable to write data: [Errno 28] No space left on device", want: true},
+		{line: "OSError: [Errno 28] No space left on device: 'video.mp4.part'", want: true},
+		{line: "ERROR: There is not enough space on the disk", want: true},
+		{line: "write error: ENOSPC", want: true},
+		{line: "[download]  45.0% of 1.20GiB at 2.00MiB/s ETA 00:30", want: false},
+		{line: "ERROR: Video unavailable", want: false},
+	}
+
+	for _, test := range tests {
+		if got := IsNoSpace(test.line); got != test.want {
+			t.Errorf("IsNoSpace(%q) is %v, want %v", test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux || darwin || freebsd
+
+package diskspace
+
+import "syscall"
+
+func free(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), nil
+}
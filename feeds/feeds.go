@@ -0,0 +1,165 @@
+package feeds
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Fetch downloads and parses the feed at url.
+func Fetch(url string) ([]Entry, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, response.Status)
+	}
+
+	return Parse(response.Body)
+}
+
+// All returns the feeds from the config file followed by those added from the
+// command line.
+func All(cfg util.Config) ([]util.FeedConfig, error) {
+	feeds := append([]util.FeedConfig{}, cfg.Feeds...)
+
+	stored, err := data.GetAllFeeds()
+	if err != nil {
+		return feeds, err
+	}
+	for _, feed := range stored {
+		feeds = append(feeds, util.FeedConfig{
+			URL:        feed.Url,
+			Preset:     feed.Preset,
+			TitleRegex: feed.TitleRegex,
+			After:      feed.DateAfter,
+			Enclosures: feed.Enclosures,
+		})
+	}
+
+	return feeds, nil
+}
+
+// Validate checks the rules of a feed.
+func Validate(cfg util.Config, feed util.FeedConfig) error {
+	if len(feed.URL) == 0 {
+		return fmt.Errorf("a feed needs a URL")
+	}
+	if len(feed.Preset) > 0 {
+		if _, ok := cfg.Preset(feed.Preset); !ok {
+			return fmt.Errorf("unknown preset %q", feed.Preset)
+		}
+	}
+	if _, err := regexp.Compile(feed.TitleRegex); err != nil {
+		return fmt.Errorf("invalid title regex: %v", err)
+	}
+	if len(feed.After) > 0 {
+		if _, err := time.Parse("20060102", feed.After); err != nil {
+			return fmt.Errorf("date %q should be in the form YYYYMMDD", feed.After)
+		}
+	}
+	return nil
+}
+
+// Check fetches a feed and queues every entry that hasn't been seen before,
+// matches the feed's rules and isn't already in the queue or history,
+// returning how many were queued. The first check of a feed without a date
+// rule only remembers the entries already there.
+func Check(cfg util.Config, feed util.FeedConfig) (int, error) {
+	if err := Validate(cfg, feed); err != nil {
+		return 0, fmt.Errorf("%s: %v", feed.URL, err)
+	}
+	titlePattern := regexp.MustCompile(feed.TitleRegex)
+
+	lastChecked, err := data.GetFeedLastChecked(feed.URL)
+	if err != nil {
+		return 0, err
+	}
+	seedOnly := !lastChecked.Valid && len(feed.After) == 0
+
+	var after time.Time
+	if len(feed.After) > 0 {
+		after, _ = time.Parse("20060102", feed.After)
+	}
+
+	entries, err := Fetch(feed.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	preset, _ := cfg.Preset(feed.Preset)
+	added := 0
+	for _, entry := range entries {
+		seen, err := data.IsFeedEntrySeen(feed.URL, entry.ID)
+		if err != nil {
+			return added, err
+		}
+		if seen {
+			continue
+		}
+
+		url := entry.DownloadURL(feed.Enclosures)
+		queue := !seedOnly && len(url) > 0 && titlePattern.MatchString(entry.Title) &&
+			(after.IsZero() || entry.Published.IsZero() || !entry.Published.Before(after))
+		if queue {
+			// videos already added some other way aren't queued twice
+			duplicates, err := data.FindDuplicateQueueItems(url)
+			if err != nil {
+				return added, err
+			}
+			queue = len(duplicates) == 0
+		}
+		if queue {
+			if err := data.InsertPresetQueueItem(url, util.SanitizeFileName(entry.Title), preset); err != nil {
+				return added, err
+			}
+			added++
+		}
+
+		// only marked once queued, so an entry that failed to go in is tried again
+		if err := data.MarkFeedEntrySeen(feed.URL, entry.ID); err != nil {
+			return added, err
+		}
+	}
+
+	return added, data.UpdateFeedLastChecked(feed.URL, time.Now())
+}
+
+// CheckDue checks every feed whose interval has elapsed and returns how many
+// items were queued in total.
+func CheckDue(cfg util.Config, now time.Time) (int, error) {
+	all, err := All(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	interval := time.Duration(cfg.Settings.FeedInterval) * time.Minute
+	added := 0
+	var firstErr error
+	for _, feed := range all {
+		lastChecked, err := data.GetFeedLastChecked(feed.URL)
+		if err != nil {
+			return added, err
+		}
+		if lastChecked.Valid && lastChecked.Time.Add(interval).After(now) {
+			continue
+		}
+
+		n, err := Check(cfg, feed)
+		added += n
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return added, firstErr
+}
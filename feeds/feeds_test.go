@@ -0,0 +1,143 @@
+package feeds
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// openDatabase opens an empty database for the test with the feeds given.
+func openDatabase(t *testing.T, feeds ...util.FeedConfig) util.Config {
+	t.Helper()
+	cfg := util.Config{
+		Settings: util.SettingsConfig{
+			DatabasePath: filepath.Join(t.TempDir(), "test.db"),
+			FeedInterval: 60,
+		},
+		Presets: []util.PresetConfig{
+			{Name: "audio", AudioOnly: true, AudioFormat: "mp3"},
+			{Name: "video", EmbedThumbnail: true},
+		},
+		Feeds: feeds,
+	}
+	if err := data.Open(cfg.Settings); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = data.CloseDatabase() })
+	return cfg
+}
+
+// queued returns the queued items as "preset url" lines.
+func queued(t *testing.T) []string {
+	t.Helper()
+	items, err := data.GetAllQueueItems("queued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{}
+	for _, item := range items {
+		lines = append(lines, item.Preset+" "+item.VideoId)
+	}
+	return lines
+}
+
+func TestCheckSeedsFirstCheck(t *testing.T) {
+	server := serve(t)
+	feed := util.FeedConfig{URL: server.URL + "/rss.xml"}
+	cfg := openDatabase(t, feed)
+
+	added, err := Check(cfg, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 0 {
+		t.Errorf("first check queued %d items, want 0", added)
+	}
+	lastChecked, err := data.GetFeedLastChecked(feed.URL)
+	if err != nil || !lastChecked.Valid {
+		t.Fatalf("first check didn't record when it ran (%v)", err)
+	}
+
+	if added, err := Check(cfg, feed); err != nil || added != 0 {
+		t.Errorf("second check queued %d items (%v), want 0", added, err)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	server := serve(t)
+	videos := util.FeedConfig{URL: server.URL + "/rss.xml", Preset: "video", TitleRegex: "^Episode", After: "20240201"}
+	podcast := util.FeedConfig{URL: server.URL + "/podcast.xml", Preset: "audio", After: "20240101", Enclosures: true}
+	talks := util.FeedConfig{URL: server.URL + "/atom.xml", TitleRegex: "(?i)second", After: "20240101"}
+	cfg := openDatabase(t, videos, podcast, talks)
+
+	added, err := CheckDue(cfg, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		// Episode 1 is older than the date rule and the other post doesn't match the title rule
+		"video https://videos.example.com/watch/2",
+		"audio https://media.example.com/show-12.mp3",
+		"audio https://media.example.com/show-11.mp3",
+		" https://talks.example.com/2",
+	}
+	if added != len(want) {
+		t.Errorf("queued %d items, want %d", added, len(want))
+	}
+	if got := queued(t); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("queued\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	items, _ := data.GetAllQueueItems("queued")
+	if !items[1].Options.Bool(ytdlp.AudioOnly) || items[1].AudioFormat != "mp3" {
+		t.Errorf("podcast episode doesn't use the audio preset: %+v", items[1])
+	}
+
+	// nothing is due again until the interval has passed
+	if added, err := CheckDue(cfg, time.Now()); err != nil || added != 0 {
+		t.Errorf("checking again straight away queued %d items (%v), want 0", added, err)
+	}
+	if added, err := CheckDue(cfg, time.Now().Add(2*time.Hour)); err != nil || added != 0 {
+		t.Errorf("checking after the interval queued %d items (%v), want 0 as all were seen", added, err)
+	}
+}
+
+func TestCheckSkipsDuplicates(t *testing.T) {
+	server := serve(t)
+	feed := util.FeedConfig{URL: server.URL + "/youtube.xml", After: "20240101"}
+	cfg := openDatabase(t, feed)
+
+	if err := data.InsertQueueItem(data.QueueItem{VideoId: "https://youtu.be/ffffffffff2", OutputName: "added by hand"}); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := Check(cfg, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Errorf("queued %d items, want 1", added)
+	}
+	want := []string{" https://youtu.be/ffffffffff2", " https://www.youtube.com/watch?v=ffffffffff1"}
+	if got := queued(t); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("queued %v, want %v", got, want)
+	}
+}
+
+func TestCheckInvalidRules(t *testing.T) {
+	server := serve(t)
+	feed := util.FeedConfig{URL: server.URL + "/rss.xml", TitleRegex: "("}
+	cfg := openDatabase(t, feed)
+
+	if _, err := Check(cfg, feed); err == nil {
+		t.Error("checked a feed with an invalid title regex")
+	}
+	if _, err := Check(cfg, util.FeedConfig{URL: feed.URL, Preset: "missing"}); err == nil {
+		t.Error("checked a feed with an unknown preset")
+	}
+}
@@ -0,0 +1,212 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single item of an RSS or Atom feed.
+type Entry struct {
+	ID            string
+	Title         string
+	Link          string
+	EnclosureURL  string
+	EnclosureType string
+	Published     time.Time
+	Duration      int
+}
+
+// DownloadURL returns what should be handed to yt-dlp for the entry, the
+// enclosure for podcasts and the entry's page otherwise.
+func (e Entry) DownloadURL(preferEnclosure bool) string {
+	if (preferEnclosure || len(e.Link) == 0) && len(e.EnclosureURL) > 0 {
+		return e.EnclosureURL
+	}
+	return e.Link
+}
+
+type rssFeed struct {
+	Items []rssItem `xml:"channel>item"`
+}
+
+type rssItem struct {
+	Title     string `xml:"title"`
+	Link      string `xml:"link"`
+	GUID      string `xml:"guid"`
+	PubDate   string `xml:"pubDate"`
+	Enclosure struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Duration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+}
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+}
+
+// Parse reads an RSS 2.0 or Atom feed, including YouTube channel feeds and
+// podcast feeds with enclosures.
+func Parse(r io.Reader) ([]Entry, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(content)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(content)
+	case "feed":
+		return parseAtom(content)
+	default:
+		return nil, fmt.Errorf("unsupported feed format <%s>", root)
+	}
+}
+
+// rootElement returns the local name of the document's first element.
+func rootElement(content []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("not a feed: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func parseRSS(content []byte) ([]Entry, error) {
+	var feed rssFeed
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, item := range feed.Items {
+		entry := Entry{
+			ID:            strings.TrimSpace(item.GUID),
+			Title:         strings.TrimSpace(item.Title),
+			Link:          strings.TrimSpace(item.Link),
+			EnclosureURL:  strings.TrimSpace(item.Enclosure.URL),
+			EnclosureType: item.Enclosure.Type,
+			Published:     parseTime(item.PubDate),
+			Duration:      parseDuration(item.Duration),
+		}
+		if len(entry.ID) == 0 {
+			entry.ID = entry.DownloadURL(true)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func parseAtom(content []byte) ([]Entry, error) {
+	var feed atomFeed
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, item := range feed.Entries {
+		entry := Entry{
+			ID:    strings.TrimSpace(item.ID),
+			Title: strings.TrimSpace(item.Title),
+		}
+		for _, link := range item.Links {
+			switch link.Rel {
+			case "", "alternate":
+				if len(entry.Link) == 0 {
+					entry.Link = link.Href
+				}
+			case "enclosure":
+				entry.EnclosureURL = link.Href
+				entry.EnclosureType = link.Type
+			}
+		}
+		if len(entry.Link) == 0 && len(item.VideoID) > 0 {
+			entry.Link = fmt.Sprintf("https://www.youtube.com/watch?v=%s", item.VideoID)
+		}
+		entry.Published = parseTime(item.Published)
+		if entry.Published.IsZero() {
+			entry.Published = parseTime(item.Updated)
+		}
+		if len(entry.ID) == 0 {
+			entry.ID = entry.DownloadURL(true)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// timeLayouts are the date formats seen in the wild in RSS and Atom feeds.
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02",
+}
+
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration reads an itunes:duration, either seconds or [HH:]MM:SS.
+func parseDuration(value string) int {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0
+	}
+
+	seconds := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds
+}
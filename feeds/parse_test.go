@@ -0,0 +1,121 @@
+package feeds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve serves the feeds in testdata for the test.
+func serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    []Entry
+		enclose bool
+		links   []string
+	}{
+		{
+			file: "rss.xml",
+			want: []Entry{
+				{ID: "videos-2", Title: "Episode 2: Tuning", Link: "https://videos.example.com/watch/2", Published: time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+				{ID: "videos-bts", Title: "Behind the scenes", Link: "https://videos.example.com/watch/bts", Published: time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)},
+				// without a guid the link identifies the entry
+				{ID: "https://videos.example.com/watch/1", Title: "Episode 1: Setup", Link: "https://videos.example.com/watch/1", Published: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+			},
+			links: []string{"https://videos.example.com/watch/2", "https://videos.example.com/watch/bts", "https://videos.example.com/watch/1"},
+		},
+		{
+			file: "atom.xml",
+			want: []Entry{
+				{ID: "urn:talks:2", Title: "Second talk", Link: "https://talks.example.com/2", EnclosureURL: "https://cdn.example.com/talks/2.mp4", EnclosureType: "video/mp4", Published: time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC)},
+				// updated stands in for a missing published date
+				{ID: "urn:talks:1", Title: "First talk", Link: "https://talks.example.com/1", Published: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)},
+			},
+			enclose: true,
+			links:   []string{"https://cdn.example.com/talks/2.mp4", "https://talks.example.com/1"},
+		},
+		{
+			file: "youtube.xml",
+			want: []Entry{
+				{ID: "yt:video:ffffffffff1", Title: "Latest upload", Link: "https://www.youtube.com/watch?v=ffffffffff1", Published: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)},
+				// the video id makes a link when the entry has none
+				{ID: "yt:video:ffffffffff2", Title: "Older upload", Link: "https://www.youtube.com/watch?v=ffffffffff2", Published: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+			},
+			links: []string{"https://www.youtube.com/watch?v=ffffffffff1", "https://www.youtube.com/watch?v=ffffffffff2"},
+		},
+		{
+			file: "podcast.xml",
+			want: []Entry{
+				{ID: "show-12", Title: "Show 12", Link: "https://podcast.example.com/12", EnclosureURL: "https://media.example.com/show-12.mp3", EnclosureType: "audio/mpeg", Published: time.Date(2024, 6, 12, 6, 0, 0, 0, time.UTC), Duration: 3723},
+				{ID: "show-11", Title: "Show 11", EnclosureURL: "https://media.example.com/show-11.mp3", EnclosureType: "audio/mpeg", Published: time.Date(2024, 6, 5, 6, 0, 0, 0, time.UTC), Duration: 1800},
+			},
+			// the page is preferred unless enclosures are asked for, an
+			// entry without one falls back to its enclosure
+			links: []string{"https://podcast.example.com/12", "https://media.example.com/show-11.mp3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			file, err := os.Open("testdata/" + test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			entries, err := Parse(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(test.want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(test.want))
+			}
+			for i, entry := range entries {
+				want := test.want[i]
+				if entry.ID != want.ID || entry.Title != want.Title || entry.Link != want.Link ||
+					entry.EnclosureURL != want.EnclosureURL || entry.EnclosureType != want.EnclosureType ||
+					!entry.Published.Equal(want.Published) || entry.Duration != want.Duration {
+					t.Errorf("entry %d is %+v, want %+v", i, entry, want)
+				}
+				if link := entry.DownloadURL(test.enclose); link != test.links[i] {
+					t.Errorf("entry %d downloads %s, want %s", i, link, test.links[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsOtherDocuments(t *testing.T) {
+	if _, err := Parse(strings.NewReader(`<html><body>not a feed</body></html>`)); err == nil {
+		t.Error("parsed an HTML page as a feed")
+	}
+	if _, err := Parse(strings.NewReader(`not xml at all`)); err == nil {
+		t.Error("parsed plain text as a feed")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := serve(t)
+
+	entries, err := Fetch(server.URL + "/podcast.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].EnclosureURL != "https://media.example.com/show-12.mp3" {
+		t.Errorf("fetched %+v", entries)
+	}
+
+	if _, err := Fetch(server.URL + "/missing.xml"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("fetching a missing feed returned %v, want a 404 error", err)
+	}
+}
@@ -1,24 +1,62 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jim-at-jibba/telecharger/cli"
 	"github.com/jim-at-jibba/telecharger/data"
 	"github.com/jim-at-jibba/telecharger/tui"
 	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
 )
 
 func main() {
+	configFile := flag.String("config", "", "read settings from this file instead of the one in the config directory")
+	database := flag.String("db", "", "use this database file instead of the profile's")
+	profile := flag.String("profile", os.Getenv(util.EnvPrefix+"PROFILE"), "use the settings, presets and database of this profile")
+	flag.Usage = func() { cli.Usage(os.Stderr) }
+	flag.Parse()
+	args := flag.Args()
+
+	if err := util.SetProfile(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	util.SetConfigFile(*configFile)
 	cfg, err := util.ParseConfig()
 	if err != nil {
-		log.Println(err)
+		// the config command is how a broken config gets looked at and fixed
+		if len(args) == 0 || args[0] != "config" {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if len(cfg.Settings.YtdlpPath) > 0 {
+		ytdlp.Binary = cfg.Settings.YtdlpPath
 	}
 
-	data.OpenDatabase()
-	data.CreateQueueTable()
+	data.SetDatabaseFile(*database)
+	if len(os.Getenv("DEBUG")) > 0 && len(*database) == 0 && len(cfg.Settings.DatabasePath) == 0 {
+		path, _ := data.DatabasePath(cfg.Settings)
+		fmt.Fprintf(os.Stderr, "DEBUG is set, using the development database %s\n", path)
+	}
+	if err := data.Open(cfg.Settings); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		if err := cli.Run(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if cfg.Settings.EnableLogging {
 		f, err := tea.LogToFile("debug.log", "debug")
@@ -29,7 +67,7 @@ func main() {
 		}
 		defer f.Close()
 	}
-	tui.Models = []tea.Model{tui.InitialModel(cfg), tui.NewForm()}
+	tui.Models = tui.NewModels(cfg, 0, 0)
 	m := tui.Models[tui.Info]
 	tui.P = tea.NewProgram(m)
 
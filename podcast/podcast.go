@@ -0,0 +1,309 @@
+package podcast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// Options selects the downloads that go in a feed and how the feed is described.
+type Options struct {
+	Title   string
+	Preset  string
+	Folder  string
+	BaseURL string
+}
+
+// Episode is a completed audio download and the file it was saved to.
+type Episode struct {
+	Item *data.QueueItem
+	Path string
+	Size int64
+}
+
+// mimeTypes maps the audio formats yt-dlp produces to enclosure types.
+var mimeTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".opus": "audio/ogg",
+	".ogg":  "audio/ogg",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".alac": "audio/mp4",
+}
+
+// Episodes returns the completed audio downloads matching opts whose files are
+// still on disk, newest first.
+func Episodes(cfg util.Config, opts Options) ([]Episode, error) {
+	items, err := data.GetAllQueueItems("completed")
+	if err != nil {
+		return nil, err
+	}
+
+	folder := ""
+	if len(opts.Folder) > 0 {
+		folder, err = filepath.Abs(opts.Folder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	episodes := []Episode{}
+	for _, item := range items {
+		if !item.Options.Bool(ytdlp.AudioOnly) {
+			continue
+		}
+		if len(opts.Preset) > 0 && item.Preset != opts.Preset {
+			continue
+		}
+
+		path, info := resolvePath(cfg, item)
+		if info == nil {
+			continue
+		}
+		if len(folder) > 0 {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(folder, abs)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+		}
+
+		episodes = append(episodes, Episode{Item: item, Path: path, Size: info.Size()})
+	}
+
+	// newest first, the order podcast apps expect
+	sort.SliceStable(episodes, func(i, j int) bool {
+		a, b := episodes[i].Item, episodes[j].Item
+		if a.CompletedAt.Valid != b.CompletedAt.Valid {
+			return a.CompletedAt.Valid
+		}
+		if a.CompletedAt.Valid && !a.CompletedAt.Time.Equal(b.CompletedAt.Time) {
+			return a.CompletedAt.Time.After(b.CompletedAt.Time)
+		}
+		return a.Id > b.Id
+	})
+
+	return episodes, nil
+}
+
+// resolvePath finds the downloaded file of an item. Items downloaded before
+// file paths were recorded are looked for under the download folder by name.
+func resolvePath(cfg util.Config, item *data.QueueItem) (string, os.FileInfo) {
+	candidates := []string{}
+	if len(item.FilePath) > 0 {
+		candidates = append(candidates, item.FilePath)
+	}
+	if len(item.OutputName) > 0 {
+		formats := []string{item.AudioFormat, "m4a", "mp3", "opus", "ogg", "aac", "flac", "wav"}
+		for _, format := range formats {
+			if len(format) > 0 {
+				candidates = append(candidates, filepath.Join(cfg.Settings.DownloadFolder, fmt.Sprintf("%s.%s", item.OutputName, format)))
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, info
+		}
+	}
+
+	return "", nil
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type guid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type item struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description,omitempty"`
+	Link        string       `xml:"link,omitempty"`
+	GUID        guid         `xml:"guid"`
+	PubDate     string       `xml:"pubDate,omitempty"`
+	Enclosure   enclosure    `xml:"enclosure"`
+	Author      string       `xml:"itunes:author,omitempty"`
+	Duration    string       `xml:"itunes:duration,omitempty"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+}
+
+type channel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	Language    string       `xml:"language"`
+	Generator   string       `xml:"generator"`
+	Author      string       `xml:"itunes:author"`
+	Explicit    string       `xml:"itunes:explicit"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+	Items       []item       `xml:"item"`
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Itunes  string   `xml:"xmlns:itunes,attr"`
+	Channel channel  `xml:"channel"`
+}
+
+// FileURL returns the address an episode's file is served from.
+func FileURL(baseURL string, episode Episode) string {
+	return fmt.Sprintf("%s/files/%d/%s", strings.TrimSuffix(baseURL, "/"), episode.Item.Id, url.PathEscape(filepath.Base(episode.Path)))
+}
+
+// Write writes a podcast RSS feed for the episodes with enclosures pointing at
+// opts.BaseURL.
+func Write(w io.Writer, opts Options, episodes []Episode) error {
+	title := opts.Title
+	if len(title) == 0 {
+		title = "telecharger"
+	}
+
+	feed := rss{
+		Version: "2.0",
+		Itunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: channel{
+			Title:       title,
+			Link:        strings.TrimSuffix(opts.BaseURL, "/") + "/feed.xml",
+			Description: "Audio downloaded with telecharger",
+			Language:    "en",
+			Generator:   "telecharger",
+			Author:      "telecharger",
+			Explicit:    "false",
+		},
+	}
+
+	for _, episode := range episodes {
+		episodeTitle := episode.Item.Title
+		if len(episodeTitle) == 0 {
+			episodeTitle = episode.Item.OutputName
+		}
+
+		entry := item{
+			Title:       episodeTitle,
+			Description: episodeTitle,
+			Link:        episode.Item.WebpageUrl,
+			GUID:        guid{Value: episode.Item.VideoKey},
+			Enclosure: enclosure{
+				URL:    FileURL(opts.BaseURL, episode),
+				Length: episode.Size,
+				Type:   mimeType(episode.Path),
+			},
+			Author:   episode.Item.Uploader,
+			Duration: formatDuration(episode.Item.Duration),
+		}
+		if len(episode.Item.VideoKey) == 0 {
+			entry.GUID.Value = strconv.Itoa(episode.Item.Id)
+		}
+		if episode.Item.CompletedAt.Valid {
+			entry.PubDate = episode.Item.CompletedAt.Time.Format(time.RFC1123Z)
+		}
+		if len(episode.Item.Thumbnail) > 0 {
+			entry.Image = &itunesImage{Href: episode.Item.Thumbnail}
+			if feed.Channel.Image == nil {
+				feed.Channel.Image = &itunesImage{Href: episode.Item.Thumbnail}
+			}
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(feed)
+}
+
+// Handler serves the feed at /feed.xml and the files of its episodes under
+// /files/, building the feed afresh on every request so new downloads show up.
+func Handler(cfg util.Config, opts Options) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		episodes, err := Episodes(cfg, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		feedOpts := opts
+		if len(feedOpts.BaseURL) == 0 {
+			feedOpts.BaseURL = "http://" + r.Host
+		}
+
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		_ = Write(w, feedOpts, episodes)
+	})
+
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/files/"), "/", 2)
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		// only files of episodes in the feed are served
+		episodes, err := Episodes(cfg, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, episode := range episodes {
+			if episode.Item.Id == id {
+				w.Header().Set("Content-Type", mimeType(episode.Path))
+				http.ServeFile(w, r, episode.Path)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+
+	return mux
+}
+
+func mimeType(path string) string {
+	if mimeType, ok := mimeTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return mimeType
+	}
+	return "audio/mpeg"
+}
+
+// formatDuration renders seconds as HH:MM:SS for itunes:duration.
+func formatDuration(seconds float64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
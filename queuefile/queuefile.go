@@ -0,0 +1,501 @@
+package queuefile
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// Formats that the queue can be exported to and imported from. Batch files are
+// yt-dlp's -a files, a URL per line with comments, and can only be imported.
+const (
+	JSON  = "json"
+	CSV   = "csv"
+	URLs  = "urls"
+	Batch = "batch"
+)
+
+// Formats lists the formats in the order they are shown in help.
+var Formats = []string{JSON, CSV, URLs, Batch}
+
+// Record is a queue item as it is written to JSON and CSV files.
+type Record struct {
+	URL           string     `json:"url"`
+	OutputName    string     `json:"output_name,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	Preset        string     `json:"preset,omitempty"`
+	Priority      int        `json:"priority,omitempty"`
+	AudioFormat   string     `json:"audio_format,omitempty"`
+	ExtraCommands string     `json:"extra_commands,omitempty"`
+	RateLimit     string     `json:"rate_limit,omitempty"`
+	NotBefore     *time.Time `json:"not_before,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	// Options are the registered yt-dlp options that differ from their default.
+	Options     ytdlp.Options `json:"options,omitempty"`
+	Title       string        `json:"title,omitempty"`
+	Uploader    string        `json:"uploader,omitempty"`
+	Duration    float64       `json:"duration,omitempty"`
+	FilePath    string        `json:"file_path,omitempty"`
+	FileSize    int64         `json:"file_size,omitempty"`
+	WebpageURL  string        `json:"webpage_url,omitempty"`
+	StartedAt   *time.Time    `json:"started_at,omitempty"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	ArchivedAt  *time.Time    `json:"archived_at,omitempty"`
+
+	// AudioOnly and EmbedThumbnail are only read, from files exported before
+	// they became options.
+	AudioOnly      bool `json:"audio_only,omitempty"`
+	EmbedThumbnail bool `json:"embed_thumbnail,omitempty"`
+}
+
+// csvColumns are the columns of CSV files, in order, with a column for each
+// registered option after tags.
+func csvColumns() []string {
+	columns := []string{"url", "output_name", "status", "preset", "priority", "audio_format", "extra_commands", "rate_limit", "not_before", "tags"}
+	for _, option := range ytdlp.Registered() {
+		columns = append(columns, option.Name)
+	}
+	return append(columns, "title", "uploader", "duration", "file_path", "file_size", "webpage_url", "started_at", "completed_at", "archived_at")
+}
+
+// FormatFromPath guesses the format of a file from its extension, treating
+// anything that isn't JSON or CSV as a batch file.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".csv":
+		return CSV
+	}
+	return Batch
+}
+
+// checkFormat returns an error for unknown formats.
+func checkFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func timePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// NewRecord converts a queue item for export.
+func NewRecord(item *data.QueueItem) Record {
+	return Record{
+		URL:           item.VideoId,
+		OutputName:    item.OutputName,
+		Status:        item.Status,
+		Preset:        item.Preset,
+		Priority:      item.Priority,
+		AudioFormat:   item.AudioFormat,
+		ExtraCommands: item.ExtraCommands,
+		RateLimit:     item.RateLimit,
+		NotBefore:     timePointer(item.NotBefore),
+		Tags:          item.Tags,
+		Options:       item.Options,
+		Title:         item.Title,
+		Uploader:      item.Uploader,
+		Duration:      item.Duration,
+		FilePath:      item.FilePath,
+		FileSize:      item.FileSize,
+		WebpageURL:    item.WebpageUrl,
+		StartedAt:     timePointer(item.StartedAt),
+		CompletedAt:   timePointer(item.CompletedAt),
+		ArchivedAt:    timePointer(item.ArchivedAt),
+	}
+}
+
+// QueueItem converts an imported record into a queue item. Downloads that were
+// running when the file was written are queued again.
+func (r Record) QueueItem() data.QueueItem {
+	status := r.Status
+	if status == "downloading" {
+		status = "queued"
+	}
+	options := r.Options.Clone()
+	if r.AudioOnly {
+		options.SetBool(ytdlp.AudioOnly, true)
+	}
+	if r.EmbedThumbnail {
+		options.SetBool(ytdlp.EmbedThumbnail, true)
+	}
+	return data.QueueItem{
+		VideoId:       r.URL,
+		OutputName:    r.OutputName,
+		Status:        status,
+		Preset:        r.Preset,
+		Priority:      r.Priority,
+		AudioFormat:   r.AudioFormat,
+		ExtraCommands: r.ExtraCommands,
+		RateLimit:     r.RateLimit,
+		NotBefore:     nullTime(r.NotBefore),
+		Tags:          r.Tags,
+		Options:       options,
+		Title:         r.Title,
+		Uploader:      r.Uploader,
+		Duration:      r.Duration,
+		FilePath:      r.FilePath,
+		FileSize:      r.FileSize,
+		WebpageUrl:    r.WebpageURL,
+		StartedAt:     nullTime(r.StartedAt),
+		CompletedAt:   nullTime(r.CompletedAt),
+		ArchivedAt:    nullTime(r.ArchivedAt),
+	}
+}
+
+// Export writes items to w in format.
+func Export(w io.Writer, format string, items []*data.QueueItem) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	records := []Record{}
+	for _, item := range items {
+		records = append(records, NewRecord(item))
+	}
+
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case CSV:
+		return writeCSV(w, records)
+	default:
+		for _, record := range records {
+			if _, err := fmt.Fprintln(w, record.URL); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func writeCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns()); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.URL, r.OutputName, r.Status, r.Preset, strconv.Itoa(r.Priority), r.AudioFormat, r.ExtraCommands, r.RateLimit, formatTime(r.NotBefore),
+			strings.Join(r.Tags, ","),
+		}
+		for _, option := range ytdlp.Registered() {
+			row = append(row, r.Options.Value(option.Name))
+		}
+		row = append(row, r.Title, r.Uploader,
+			strconv.FormatFloat(r.Duration, 'f', -1, 64), r.FilePath, strconv.FormatInt(r.FileSize, 10), r.WebpageURL,
+			formatTime(r.StartedAt), formatTime(r.CompletedAt), formatTime(r.ArchivedAt),
+		)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Read parses the records of a file in format.
+func Read(r io.Reader, format string) ([]Record, error) {
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case JSON:
+		return readJSON(r)
+	case CSV:
+		return readCSV(r)
+	default:
+		return readBatch(r)
+	}
+}
+
+// readJSON reads an array of records, keeping track of the line each starts on
+// so problems can be reported against it.
+func readJSON(r io.Reader) ([]Record, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("invalid JSON: expected an array of queue items")
+	}
+
+	records := []Record{}
+	for decoder.More() {
+		offset := int(decoder.InputOffset())
+		offset += len(content[offset:]) - len(bytes.TrimLeft(content[offset:], " \t\r\n,"))
+		line := bytes.Count(content[:offset], []byte("\n")) + 1
+
+		var record Record
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("invalid JSON: line %d: %v", line, err)
+		}
+		if err := checkStatus(record.Status); err != nil {
+			return nil, fmt.Errorf("line %d: status: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return records, nil
+}
+
+// checkStatus makes sure an imported status is one the queue uses, an empty
+// one meaning queued.
+func checkStatus(status string) error {
+	if len(status) == 0 {
+		return nil
+	}
+	for _, known := range data.Statuses {
+		if status == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown status %q, expected one of %s", status, strings.Join(data.Statuses, ", "))
+}
+
+// readBatch reads a URL per line, skipping blank lines and the comments that
+// yt-dlp allows in batch files.
+func readBatch(r io.Reader) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "]") {
+			continue
+		}
+		records = append(records, Record{URL: line})
+	}
+	return records, scanner.Err()
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(rows) == 0 {
+		return []Record{}, nil
+	}
+
+	// columns are found by name so files written by hand can leave some out
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, fmt.Errorf("invalid CSV: the first row needs a url column")
+	}
+
+	records := []Record{}
+	for n, row := range rows[1:] {
+		line := n + 2
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		record := Record{
+			URL:           value("url"),
+			OutputName:    value("output_name"),
+			Status:        value("status"),
+			Preset:        value("preset"),
+			AudioFormat:   value("audio_format"),
+			ExtraCommands: value("extra_commands"),
+			RateLimit:     value("rate_limit"),
+			Title:         value("title"),
+			Uploader:      value("uploader"),
+			FilePath:      value("file_path"),
+			WebpageURL:    value("webpage_url"),
+		}
+		if err := checkStatus(record.Status); err != nil {
+			return nil, fmt.Errorf("line %d: status: %v", line, err)
+		}
+		for _, option := range ytdlp.Registered() {
+			if err := option.Validate(value(option.Name)); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v", line, option.Name, err)
+			}
+			record.Options.Set(option.Name, value(option.Name))
+		}
+		var err error
+		if record.Tags, err = data.ParseTags(value("tags")); err != nil {
+			return nil, fmt.Errorf("line %d: tags: %v", line, err)
+		}
+		if record.Priority, err = parseInt(value("priority")); err != nil {
+			return nil, fmt.Errorf("line %d: priority: %v", line, err)
+		}
+		if record.Duration, err = parseFloat(value("duration")); err != nil {
+			return nil, fmt.Errorf("line %d: duration: %v", line, err)
+		}
+		fileSize, err := parseInt(value("file_size"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: file_size: %v", line, err)
+		}
+		record.FileSize = int64(fileSize)
+		for name, target := range map[string]**time.Time{"not_before": &record.NotBefore, "started_at": &record.StartedAt, "completed_at": &record.CompletedAt, "archived_at": &record.ArchivedAt} {
+			if *target, err = parseTime(value(name)); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v", line, name, err)
+			}
+		}
+
+		if len(record.URL) > 0 {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func parseInt(value string) (int, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func parseFloat(value string) (float64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseBool(value string) (bool, error) {
+	if len(value) == 0 {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Plan is what an import would do: the items it adds, and the URLs it skips
+// because they are already in the queue or appear earlier in the file.
+type Plan struct {
+	Add      []data.QueueItem
+	Existing []string
+	Repeated []string
+}
+
+// NewPlan dedupes records against the queue and each other. Records without a
+// preset get defaultPreset, with that preset's options when they have none of
+// their own.
+func NewPlan(cfg util.Config, records []Record, defaultPreset string) (Plan, error) {
+	plan := Plan{}
+
+	existing, err := data.GetAllQueueItems(data.Statuses...)
+	if err != nil {
+		return plan, err
+	}
+	queued := map[string]bool{}
+	for _, item := range existing {
+		queued[item.VideoKey] = true
+	}
+
+	seen := map[string]bool{}
+	for _, record := range records {
+		key := util.VideoKey(record.URL)
+		if queued[key] {
+			plan.Existing = append(plan.Existing, record.URL)
+			continue
+		}
+		if seen[key] {
+			plan.Repeated = append(plan.Repeated, record.URL)
+			continue
+		}
+		seen[key] = true
+
+		if err := checkStatus(record.Status); err != nil {
+			return plan, fmt.Errorf("%s: %v", record.URL, err)
+		}
+		if err := record.Options.Validate(); err != nil {
+			return plan, fmt.Errorf("%s: %v", record.URL, err)
+		}
+		if record.Tags, err = data.ParseTags(strings.Join(record.Tags, ",")); err != nil {
+			return plan, fmt.Errorf("%s: %v", record.URL, err)
+		}
+
+		item := record.QueueItem()
+		if len(item.Preset) == 0 && len(defaultPreset) > 0 {
+			preset, ok := cfg.Preset(defaultPreset)
+			if !ok {
+				return plan, fmt.Errorf("unknown preset %q", defaultPreset)
+			}
+			item.Preset = preset.Name
+			if len(item.AudioFormat) == 0 && len(item.ExtraCommands) == 0 && !item.Options.Bool(ytdlp.AudioOnly) && !item.Options.Bool(ytdlp.EmbedThumbnail) {
+				item.Options.SetBool(ytdlp.AudioOnly, preset.AudioOnly)
+				item.Options.SetBool(ytdlp.EmbedThumbnail, preset.EmbedThumbnail)
+				item.AudioFormat = preset.AudioFormat
+				item.ExtraCommands = preset.ExtraCommands
+			}
+		}
+		plan.Add = append(plan.Add, item)
+	}
+
+	return plan, nil
+}
+
+// Apply adds the items of the plan to the queue.
+func (p Plan) Apply() error {
+	if len(p.Add) == 0 {
+		return nil
+	}
+	return data.InsertQueueItems(p.Add)
+}
+
+// Summary describes the plan in a sentence.
+func (p Plan) Summary() string {
+	return fmt.Sprintf("%d new, %d already in the queue, %d repeated in the file", len(p.Add), len(p.Existing), len(p.Repeated))
+}
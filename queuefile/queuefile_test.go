@@ -0,0 +1,245 @@
+package queuefile
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// openDatabase opens an empty database for the test.
+func openDatabase(t *testing.T) util.Config {
+	t.Helper()
+	cfg := util.Config{
+		Settings: util.SettingsConfig{DatabasePath: filepath.Join(t.TempDir(), "test.db")},
+		Presets: []util.PresetConfig{
+			{Name: "audio", AudioOnly: true, AudioFormat: "mp3"},
+			{Name: "video", EmbedThumbnail: true},
+		},
+	}
+	if err := data.Open(cfg.Settings); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = data.CloseDatabase() })
+	return cfg
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "queue.json", want: JSON},
+		{path: "/tmp/QUEUE.JSON", want: JSON},
+		{path: "queue.csv", want: CSV},
+		{path: "queue.txt", want: Batch},
+		{path: "queue", want: Batch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if format := FormatFromPath(test.path); format != test.want {
+				t.Errorf("format is %s, want %s", format, test.want)
+			}
+		})
+	}
+}
+
+func TestExportAndRead(t *testing.T) {
+	completed := time.Date(2024, 3, 4, 12, 30, 0, 0, time.UTC)
+	items := []*data.QueueItem{
+		{
+			VideoId:     "https://example.com/1",
+			OutputName:  "first",
+			Status:      "completed",
+			Preset:      "audio",
+			Priority:    2,
+			AudioFormat: "mp3",
+			Tags:        []string{"music", "live"},
+			Options:     ytdlp.Options{ytdlp.AudioOnly: "true", "concurrent_fragments": "4"},
+			Title:       "First, with a comma",
+			Duration:    61.5,
+			FileSize:    1024,
+			CompletedAt: sql.NullTime{Time: completed, Valid: true},
+		},
+		{VideoId: "https://example.com/2", Status: "queued", RateLimit: "500K"},
+	}
+
+	for _, format := range []string{JSON, CSV} {
+		t.Run(format, func(t *testing.T) {
+			var file bytes.Buffer
+			if err := Export(&file, format, items); err != nil {
+				t.Fatal(err)
+			}
+			records, err := Read(&file, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(items) {
+				t.Fatalf("read %d records, want %d", len(records), len(items))
+			}
+			for i, item := range items {
+				want := NewRecord(item)
+				// an empty list or set of options can come back as none, or the
+				// other way round
+				if len(want.Options) == 0 {
+					want.Options = records[i].Options
+				}
+				if len(want.Tags) == 0 {
+					want.Tags = records[i].Tags
+				}
+				if !reflect.DeepEqual(records[i], want) {
+					t.Errorf("read\n%+v\nwant\n%+v", records[i], want)
+				}
+			}
+		})
+	}
+
+	t.Run(URLs, func(t *testing.T) {
+		var file bytes.Buffer
+		if err := Export(&file, URLs, items); err != nil {
+			t.Fatal(err)
+		}
+		if file.String() != "https://example.com/1\nhttps://example.com/2\n" {
+			t.Errorf("exported %q", file.String())
+		}
+	})
+
+	if err := Export(&bytes.Buffer{}, "xml", items); err == nil {
+		t.Error("exported to an unknown format")
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		urls   string
+		err    string
+	}{
+		{
+			name:   "batch",
+			format: Batch,
+			file:   "# saved for later\nhttps://example.com/1\n\n  https://example.com/2  \n; skipped\n] skipped\n",
+			urls:   "https://example.com/1 https://example.com/2",
+		},
+		{name: "empty CSV", format: CSV},
+		{
+			name:   "CSV with some columns",
+			format: CSV,
+			file:   "URL,Preset\nhttps://example.com/1,audio\n,audio\nhttps://example.com/2\n",
+			urls:   "https://example.com/1 https://example.com/2",
+		},
+		{name: "CSV without urls", format: CSV, file: "title\nfirst\n", err: "url column"},
+		{name: "CSV status", format: CSV, file: "url,status\nhttps://example.com/1,\nhttps://example.com/2,paused\n", err: "line 3: status"},
+		{name: "CSV priority", format: CSV, file: "url,priority\nhttps://example.com/1,high\n", err: "line 2: priority"},
+		{name: "CSV option", format: CSV, file: "url,audio_only\nhttps://example.com/1,sometimes\n", err: "line 2: audio_only"},
+		{name: "CSV time", format: CSV, file: "url,completed_at\nhttps://example.com/1,yesterday\n", err: "line 2: completed_at"},
+		{
+			name:   "JSON",
+			format: JSON,
+			file:   `[{"url": "https://example.com/1"}, {"url": "https://example.com/2", "status": "error"}]`,
+			urls:   "https://example.com/1 https://example.com/2",
+		},
+		{name: "JSON object", format: JSON, file: `{"url": "https://example.com/1"}`, err: "expected an array"},
+		{
+			name:   "JSON status",
+			format: JSON,
+			file:   "[\n  {\"url\": \"https://example.com/1\"},\n  {\n    \"url\": \"https://example.com/2\",\n    \"status\": \"paused\"\n  }\n]\n",
+			err:    "line 3: status",
+		},
+		{name: "JSON field", format: JSON, file: "[\n  {\"url\": 1}\n]", err: "line 2"},
+		{name: "unknown format", format: "xml", err: "unknown format"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := Read(strings.NewReader(test.file), test.format)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("read with error %v, want one mentioning %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			urls := []string{}
+			for _, record := range records {
+				urls = append(urls, record.URL)
+			}
+			if strings.Join(urls, " ") != test.urls {
+				t.Errorf("read %v, want %s", urls, test.urls)
+			}
+		})
+	}
+}
+
+func TestRecordQueueItem(t *testing.T) {
+	item := Record{URL: "https://example.com/1", Status: "downloading", AudioOnly: true, EmbedThumbnail: true}.QueueItem()
+	if item.Status != "queued" {
+		t.Errorf("a running download is imported as %s, want queued", item.Status)
+	}
+	if !item.Options.Bool(ytdlp.AudioOnly) || !item.Options.Bool(ytdlp.EmbedThumbnail) {
+		t.Errorf("the old audio_only and embed_thumbnail fields were dropped: %v", item.Options)
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	cfg := openDatabase(t)
+	if err := data.InsertQueueItem(data.QueueItem{VideoId: "https://youtu.be/aaaaaaaaaaa"}); err != nil {
+		t.Fatal(err)
+	}
+
+	records := []Record{
+		{URL: "https://www.youtube.com/watch?v=aaaaaaaaaaa"},
+		{URL: "https://youtu.be/bbbbbbbbbbb"},
+		{URL: "https://www.youtube.com/watch?v=bbbbbbbbbbb&t=10"},
+		{URL: "https://example.com/own", Options: ytdlp.Options{ytdlp.EmbedThumbnail: "true"}},
+		{URL: "https://example.com/video", Preset: "video"},
+	}
+	plan, err := NewPlan(cfg, records, "audio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Summary() != "3 new, 1 already in the queue, 1 repeated in the file" {
+		t.Errorf("plan is %s", plan.Summary())
+	}
+
+	// the default preset's options are only used by records without any
+	if first := plan.Add[0]; first.Preset != "audio" || !first.Options.Bool(ytdlp.AudioOnly) || first.AudioFormat != "mp3" {
+		t.Errorf("the default preset wasn't applied: %+v", first)
+	}
+	if own := plan.Add[1]; own.Preset != "audio" || own.Options.Bool(ytdlp.AudioOnly) || len(own.AudioFormat) > 0 {
+		t.Errorf("the default preset replaced the record's options: %+v", own)
+	}
+	if video := plan.Add[2]; video.Preset != "video" || video.Options.Bool(ytdlp.AudioOnly) {
+		t.Errorf("the record's preset was replaced: %+v", video)
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewPlan(cfg, records, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Add) != 0 || len(again.Existing) != len(records) {
+		t.Errorf("planning the same import again gives %s", again.Summary())
+	}
+
+	if _, err := NewPlan(cfg, []Record{{URL: "https://example.com/new"}}, "podcast"); err == nil {
+		t.Error("planned with an unknown default preset")
+	}
+	if _, err := NewPlan(cfg, []Record{{URL: "https://example.com/new", Tags: []string{"a/b"}}}, ""); err == nil {
+		t.Error("planned with invalid tags")
+	}
+}
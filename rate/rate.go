@@ -0,0 +1,63 @@
+// Package rate reads and writes download speeds the way yt-dlp's --limit-rate
+// takes them.
+package rate
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Unlimited is the rate used when no limit applies.
+const Unlimited int64 = 0
+
+var units = []struct {
+	suffix string
+	size   int64
+}{
+	{"G", 1024 * 1024 * 1024},
+	{"M", 1024 * 1024},
+	{"K", 1024},
+}
+
+// Parse reads a rate in bytes per second written the way yt-dlp's
+// --limit-rate accepts it, such as "500K" or "2.5M". An empty value or "0"
+// means unlimited.
+func Parse(value string) (int64, error) {
+	original := value
+	value = strings.TrimSpace(strings.ToUpper(value))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "/S"), "B")
+	if len(value) == 0 {
+		return Unlimited, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.size
+			value = strings.TrimSuffix(value, unit.suffix)
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid rate %q, expected a number of bytes with an optional K, M or G suffix", original)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// Format writes a rate in the form yt-dlp accepts, using the largest unit
+// that fits.
+func Format(rate int64) string {
+	if rate <= 0 {
+		return "unlimited"
+	}
+	for _, unit := range units {
+		if rate >= unit.size {
+			return strconv.FormatFloat(math.Round(float64(rate)*100/float64(unit.size))/100, 'f', -1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(rate, 10)
+}
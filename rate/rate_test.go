@@ -0,0 +1,72 @@
+package rate
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		err   bool
+	}{
+		{value: "", want: Unlimited},
+		{value: "0", want: Unlimited},
+		{value: "500", want: 500},
+		{value: "500K", want: 500 * 1024},
+		{value: "500k", want: 500 * 1024},
+		{value: "2.5M", want: 2.5 * 1024 * 1024},
+		{value: "1G", want: 1024 * 1024 * 1024},
+		{value: " 300KB ", want: 300 * 1024},
+		{value: "1MB/s", want: 1024 * 1024},
+		{value: "1.5", want: 1},
+		{value: "fast", err: true},
+		{value: "-1M", err: true},
+		{value: "10T", err: true},
+		{value: "M", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			rate, err := Parse(test.value)
+			if test.err {
+				if err == nil {
+					t.Errorf("parsed %d, want an error", rate)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rate != test.want {
+				t.Errorf("parsed %d, want %d", rate, test.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		rate int64
+		want string
+	}{
+		{rate: Unlimited, want: "unlimited"},
+		{rate: -5, want: "unlimited"},
+		{rate: 900, want: "900"},
+		{rate: 1024, want: "1K"},
+		{rate: 300 * 1024, want: "300K"},
+		{rate: 2.5 * 1024 * 1024, want: "2.5M"},
+		{rate: 1024*1024 + 1, want: "1M"},
+		{rate: 3 * 1024 * 1024 * 1024, want: "3G"},
+	}
+
+	for _, test := range tests {
+		if got := Format(test.rate); got != test.want {
+			t.Errorf("Format(%d) is %q, want %q", test.rate, got, test.want)
+		}
+		// what is written reads back as the same rate, give or take rounding
+		if test.rate > 0 && test.rate%1024 == 0 {
+			if rate, err := Parse(Format(test.rate)); err != nil || rate != test.rate {
+				t.Errorf("%q reads back as %d (%v), want %d", Format(test.rate), rate, err, test.rate)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/rate"
+	"github.com/jim-at-jibba/telecharger/schedule"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// Steps are the rates the dashboard moves between when the limit is raised or
+// lowered at runtime. Raising past the last step removes the limit.
+var Steps = []int64{
+	128 * 1024,
+	256 * 1024,
+	512 * 1024,
+	1024 * 1024,
+	2 * 1024 * 1024,
+	5 * 1024 * 1024,
+	10 * 1024 * 1024,
+	20 * 1024 * 1024,
+	50 * 1024 * 1024,
+}
+
+// Period is a limit that applies during a download window.
+type Period struct {
+	Window schedule.Window
+	Rate   int64
+}
+
+// Limits is the global rate limit and the download windows that change it.
+type Limits struct {
+	Default int64
+	Periods []Period
+}
+
+// New reads the global limit and its schedule from the settings.
+func New(settings util.SettingsConfig) (Limits, error) {
+	var limits Limits
+	var err error
+
+	if limits.Default, err = rate.Parse(settings.RateLimit); err != nil {
+		return limits, fmt.Errorf("rate_limit: %v", err)
+	}
+
+	for _, period := range settings.RateLimitSchedule {
+		window, err := schedule.ParseWindow(period.Window)
+		if err != nil {
+			return limits, fmt.Errorf("rate_limit_schedule: %v", err)
+		}
+		limit, err := rate.Parse(period.Limit)
+		if err != nil {
+			return limits, fmt.Errorf("rate_limit_schedule: %v", err)
+		}
+		limits.Periods = append(limits.Periods, Period{Window: window, Rate: limit})
+	}
+
+	return limits, nil
+}
+
+// At returns the global limit at t, the first matching period taking
+// precedence over the default.
+func (l Limits) At(t time.Time) int64 {
+	for _, period := range l.Periods {
+		if period.Window.Contains(t) {
+			return period.Rate
+		}
+	}
+	return l.Default
+}
+
+// Raise returns the next step above limit, or unlimited past the last step.
+func Raise(limit int64) int64 {
+	if limit <= 0 {
+		return rate.Unlimited
+	}
+	for _, step := range Steps {
+		if step > limit {
+			return step
+		}
+	}
+	return rate.Unlimited
+}
+
+// Lower returns the next step below limit, staying on the first step once it
+// is reached.
+func Lower(limit int64) int64 {
+	if limit <= 0 {
+		return Steps[len(Steps)-1]
+	}
+	for i := len(Steps) - 1; i >= 0; i-- {
+		if Steps[i] < limit {
+			return Steps[i]
+		}
+	}
+	return Steps[0]
+}
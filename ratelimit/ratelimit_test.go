@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/rate"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings util.SettingsConfig
+		want     int64
+		periods  int
+		err      bool
+	}{
+		{name: "unlimited", want: rate.Unlimited},
+		{name: "default only", settings: util.SettingsConfig{RateLimit: "1M"}, want: 1024 * 1024},
+		{
+			name: "with a schedule",
+			settings: util.SettingsConfig{RateLimit: "1M", RateLimitSchedule: []util.RateLimitPeriod{
+				{Window: "09:00-18:00 mon-fri", Limit: "300K"},
+				{Window: "01:00-07:00", Limit: "0"},
+			}},
+			want:    1024 * 1024,
+			periods: 2,
+		},
+		{name: "bad default", settings: util.SettingsConfig{RateLimit: "fast"}, err: true},
+		{name: "bad window", settings: util.SettingsConfig{RateLimitSchedule: []util.RateLimitPeriod{{Window: "9-5", Limit: "1M"}}}, err: true},
+		{name: "bad period limit", settings: util.SettingsConfig{RateLimitSchedule: []util.RateLimitPeriod{{Window: "09:00-17:00", Limit: "slow"}}}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limits, err := New(test.settings)
+			if test.err {
+				if err == nil {
+					t.Errorf("read %+v, want an error", limits)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if limits.Default != test.want || len(limits.Periods) != test.periods {
+				t.Errorf("read %+v, want a default of %d and %d periods", limits, test.want, test.periods)
+			}
+		})
+	}
+}
+
+func TestAt(t *testing.T) {
+	limits, err := New(util.SettingsConfig{RateLimit: "1M", RateLimitSchedule: []util.RateLimitPeriod{
+		{Window: "09:00-18:00 mon-fri", Limit: "300K"},
+		{Window: "12:00-13:00", Limit: "0"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-03-04 is a monday
+	tests := []struct {
+		name string
+		t    time.Time
+		want int64
+	}{
+		{name: "outside every window", t: time.Date(2024, 3, 4, 8, 0, 0, 0, time.Local), want: 1024 * 1024},
+		{name: "working hours", t: time.Date(2024, 3, 4, 10, 0, 0, 0, time.Local), want: 300 * 1024},
+		{name: "the first matching window wins", t: time.Date(2024, 3, 4, 12, 30, 0, 0, time.Local), want: 300 * 1024},
+		{name: "lunch at the weekend", t: time.Date(2024, 3, 9, 12, 30, 0, 0, time.Local), want: rate.Unlimited},
+	}
+
+	for _, test := range tests {
+		if got := limits.At(test.t); got != test.want {
+			t.Errorf("%s: limit is %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRaiseAndLower(t *testing.T) {
+	tests := []struct {
+		limit        int64
+		raise, lower int64
+	}{
+		{limit: rate.Unlimited, raise: rate.Unlimited, lower: Steps[len(Steps)-1]},
+		{limit: 1, raise: Steps[0], lower: Steps[0]},
+		{limit: Steps[0], raise: Steps[1], lower: Steps[0]},
+		{limit: 300 * 1024, raise: 512 * 1024, lower: 256 * 1024},
+		{limit: Steps[len(Steps)-1], raise: rate.Unlimited, lower: Steps[len(Steps)-2]},
+		{limit: 100 * 1024 * 1024, raise: rate.Unlimited, lower: Steps[len(Steps)-1]},
+	}
+
+	for _, test := range tests {
+		if got := Raise(test.limit); got != test.raise {
+			t.Errorf("Raise(%d) is %d, want %d", test.limit, got, test.raise)
+		}
+		if got := Lower(test.limit); got != test.lower {
+			t.Errorf("Lower(%d) is %d, want %d", test.limit, got, test.lower)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a time of day range on some days of the week, such as
+// "01:00-07:00 mon-fri". A window whose end is before its start runs past
+// midnight into the next day, and one that ends when it starts, such as
+// "00:00-00:00", lasts the whole day.
+type Window struct {
+	Days  [7]bool
+	Start int
+	End   int
+}
+
+// Schedule is a set of windows. An empty schedule is always open.
+type Schedule []Window
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Parse reads windows in the form "HH:MM-HH:MM [days]", where days is a comma
+// separated list of days or day ranges such as "mon-fri,sun". Windows without
+// days apply every day.
+func Parse(windows []string) (Schedule, error) {
+	schedule := Schedule{}
+	for _, value := range windows {
+		window, err := ParseWindow(value)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, window)
+	}
+	return schedule, nil
+}
+
+// ParseWindow reads a single window, see Parse.
+func ParseWindow(value string) (Window, error) {
+	var window Window
+
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 || len(fields) > 2 {
+		return window, fmt.Errorf("invalid window %q, expected \"HH:MM-HH:MM [days]\"", value)
+	}
+
+	times := strings.Split(fields[0], "-")
+	if len(times) != 2 {
+		return window, fmt.Errorf("invalid window %q, expected \"HH:MM-HH:MM [days]\"", value)
+	}
+	var err error
+	if window.Start, err = parseClock(times[0]); err != nil {
+		return window, fmt.Errorf("invalid window %q: %v", value, err)
+	}
+	if window.End, err = parseClock(times[1]); err != nil {
+		return window, fmt.Errorf("invalid window %q: %v", value, err)
+	}
+
+	if len(fields) == 1 {
+		for i := range window.Days {
+			window.Days[i] = true
+		}
+		return window, nil
+	}
+
+	for _, part := range strings.Split(fields[1], ",") {
+		bounds := strings.Split(part, "-")
+		from, err := parseDay(bounds[0])
+		if err != nil {
+			return window, fmt.Errorf("invalid window %q: %v", value, err)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = parseDay(bounds[1]); err != nil {
+				return window, fmt.Errorf("invalid window %q: %v", value, err)
+			}
+		} else if len(bounds) > 2 {
+			return window, fmt.Errorf("invalid window %q: bad day range %q", value, part)
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			window.Days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+
+	return window, nil
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseDay reads a day given by its full name or three letter abbreviation.
+func parseDay(value string) (int, error) {
+	for i, name := range dayNames {
+		if value == name || value == strings.ToLower(time.Weekday(i).String()) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("bad day %q", value)
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := int(t.Weekday())
+
+	if w.Start == w.End {
+		return w.Days[day]
+	}
+	if w.Start < w.End {
+		return w.Days[day] && minute >= w.Start && minute < w.End
+	}
+
+	// windows past midnight belong to the day they start on
+	if minute >= w.Start {
+		return w.Days[day]
+	}
+	return minute < w.End && w.Days[(day+6)%7]
+}
+
+// Open reports whether downloads may start at t.
+func (s Schedule) Open(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, window := range s {
+		if window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the first minute from t at which the schedule is open, or
+// the zero time when no window ever opens.
+func (s Schedule) NextOpen(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for i := 0; i <= 8*24*60; i++ {
+		candidate := t.Add(time.Duration(i) * time.Minute)
+		if s.Open(candidate) {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// days returns the days of a window from the indexes of the days it has.
+func days(indexes ...int) [7]bool {
+	var days [7]bool
+	for _, i := range indexes {
+		days[i] = true
+	}
+	return days
+}
+
+func TestParseWindow(t *testing.T) {
+	every := days(0, 1, 2, 3, 4, 5, 6)
+	tests := []struct {
+		value string
+		want  Window
+		err   bool
+	}{
+		{value: "01:00-07:00", want: Window{Days: every, Start: 60, End: 420}},
+		{value: "22:00-06:00 mon-fri", want: Window{Days: days(1, 2, 3, 4, 5), Start: 1320, End: 360}},
+		{value: "00:00-00:00 sat,sun", want: Window{Days: days(0, 6)}},
+		{value: "09:30-17:45 Monday,WEDNESDAY", want: Window{Days: days(1, 3), Start: 570, End: 1065}},
+		// a range past saturday wraps round to the start of the week
+		{value: "12:00-13:00 fri-mon", want: Window{Days: days(5, 6, 0, 1), Start: 720, End: 780}},
+		{value: "12:00-13:00 thursday-sat", want: Window{Days: days(4, 5, 6), Start: 720, End: 780}},
+		{value: "", err: true},
+		{value: "01:00", err: true},
+		{value: "01:00-25:00", err: true},
+		{value: "1am-7am", err: true},
+		{value: "01:00-07:00 mon-fri extra", err: true},
+		{value: "01:00-07:00 mon-wed-fri", err: true},
+		{value: "01:00-07:00 monkey", err: true},
+		{value: "01:00-07:00 sunset", err: true},
+		{value: "01:00-07:00 thursdayz", err: true},
+		{value: "01:00-07:00 mo", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			window, err := ParseWindow(test.value)
+			if test.err {
+				if err == nil {
+					t.Errorf("parsed %+v, want an error", window)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if window != test.want {
+				t.Errorf("parsed %+v, want %+v", window, test.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	schedule, err := Parse([]string{"01:00-07:00", "22:00-06:00 mon-fri"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule) != 2 {
+		t.Errorf("parsed %d windows, want 2", len(schedule))
+	}
+	if _, err := Parse([]string{"01:00-07:00", "bad"}); err == nil {
+		t.Error("parsed a schedule with an invalid window")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	schedule, err := Parse([]string{"22:00-06:00 mon-fri", "12:00-12:00 sun"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-03-04 is a monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "monday night", t: at(4, 23, 0), want: true},
+		{name: "tuesday early morning", t: at(5, 5, 59), want: true},
+		{name: "when the window ends", t: at(5, 6, 0), want: false},
+		{name: "monday early morning", t: at(4, 3, 0), want: false},
+		{name: "saturday early morning", t: at(9, 3, 0), want: true},
+		{name: "saturday night", t: at(9, 23, 0), want: false},
+		{name: "all of sunday", t: at(10, 0, 0), want: true},
+		{name: "sunday night", t: at(10, 23, 59), want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if open := schedule.Open(test.t); open != test.want {
+				t.Errorf("open at %v is %v, want %v", test.t, open, test.want)
+			}
+		})
+	}
+
+	if !(Schedule{}).Open(at(4, 12, 0)) {
+		t.Error("an empty schedule is closed")
+	}
+
+	if next := schedule.NextOpen(at(4, 12, 30)); !next.Equal(at(4, 22, 0)) {
+		t.Errorf("next opens at %v, want monday 22:00", next)
+	}
+	if next := schedule.NextOpen(at(4, 23, 0)); !next.Equal(at(4, 23, 0)) {
+		t.Errorf("next opens at %v while open, want straight away", next)
+	}
+}
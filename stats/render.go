@@ -0,0 +1,201 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	headingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+	barStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	failedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	mutedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+// sparks are the bars of a sparkline, from lowest to highest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// labelWidth is the most a name takes up in front of a bar.
+const labelWidth = 24
+
+// FormatBytes writes a size with the largest unit that keeps it at 1 or more.
+func FormatBytes(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	size := float64(bytes)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[unit])
+}
+
+// Render draws the report as text with charts, fitting bars into width.
+func Render(r Report, width int) string {
+	barWidth := width - labelWidth - 30
+	if barWidth < 10 {
+		barWidth = 10
+	}
+	if barWidth > 50 {
+		barWidth = 50
+	}
+
+	speed := "unknown"
+	if r.AverageSpeed > 0 {
+		speed = FormatBytes(int64(r.AverageSpeed)) + "/s"
+	}
+	sections := []string{
+		section("Totals",
+			fmt.Sprintf("%d completed • %s • %.1f%% succeeded", r.Completed, failedStyle.Render(fmt.Sprintf("%d failed", r.Failed)), r.SuccessRate()*100),
+			fmt.Sprintf("%s downloaded • average speed %s", FormatBytes(r.Bytes), speed),
+		),
+		section(fmt.Sprintf("Last %d days", len(r.Days)), daysView(r.Days)...),
+		section(fmt.Sprintf("Last %d weeks", len(r.Weeks)), weeksView(r.Weeks, barWidth)...),
+		section("Top uploaders", rankedView(r.Uploaders, barWidth)...),
+		section("Top sites", rankedView(r.Sites, barWidth)...),
+		section("Disk usage", foldersView(r.Folders, barWidth)...),
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func section(title string, lines ...string) string {
+	if len(lines) == 0 {
+		lines = []string{mutedStyle.Render("nothing yet")}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append([]string{headingStyle.Render(title)}, lines...)...)
+}
+
+// sparkline draws one bar per value, scaled to the largest.
+func sparkline(values []int) string {
+	highest := 0
+	for _, value := range values {
+		if value > highest {
+			highest = value
+		}
+	}
+
+	line := []rune{}
+	for _, value := range values {
+		if value == 0 {
+			line = append(line, sparks[0])
+			continue
+		}
+		line = append(line, sparks[(value*(len(sparks)-1)+highest-1)/highest])
+	}
+	return string(line)
+}
+
+// bar draws value as a share of highest, at least one block when it isn't 0.
+func bar(value, highest float64, width int) string {
+	if highest <= 0 || value <= 0 {
+		return strings.Repeat(" ", width)
+	}
+	length := int(math.Max(1, math.Round(value/highest*float64(width))))
+	return barStyle.Render(strings.Repeat("█", length)) + strings.Repeat(" ", width-length)
+}
+
+// label fits name into the space in front of a bar, cutting off its end.
+func label(name string) string {
+	if runes := []rune(name); len(runes) > labelWidth {
+		name = string(runes[:labelWidth-2]) + "…"
+	}
+	return name + strings.Repeat(" ", labelWidth-lipgloss.Width(name))
+}
+
+// pathLabel fits a path into the space in front of a bar, cutting off its
+// start so the folder's own name stays.
+func pathLabel(path string) string {
+	if runes := []rune(path); len(runes) > labelWidth {
+		path = "…" + string(runes[len(runes)-labelWidth+2:])
+	}
+	return label(path)
+}
+
+func daysView(days []Period) []string {
+	completed, failed := []int{}, []int{}
+	total, bytes, busiest := 0, int64(0), Period{}
+	for _, day := range days {
+		completed = append(completed, day.Completed)
+		failed = append(failed, day.Failed)
+		total += day.Completed
+		bytes += day.Bytes
+		if day.Completed > busiest.Completed {
+			busiest = day
+		}
+	}
+	if total == 0 && sum(failed) == 0 {
+		return nil
+	}
+
+	gap := len(days) - 12
+	if gap < 1 {
+		gap = 1
+	}
+	lines := []string{
+		label("completed") + barStyle.Render(sparkline(completed)),
+		label("failed") + failedStyle.Render(sparkline(failed)),
+		label("") + mutedStyle.Render(days[0].Start.Format("02 Jan")+strings.Repeat(" ", gap)+days[len(days)-1].Start.Format("02 Jan")),
+		fmt.Sprintf("%d completed, %s", total, FormatBytes(bytes)),
+	}
+	if busiest.Completed > 0 {
+		lines = append(lines, fmt.Sprintf("busiest day %s with %d", busiest.Start.Format("Mon 02 Jan"), busiest.Completed))
+	}
+	return lines
+}
+
+func weeksView(weeks []Period, width int) []string {
+	highest := 0.0
+	for _, week := range weeks {
+		highest = math.Max(highest, float64(week.Completed))
+	}
+	if highest == 0 {
+		return nil
+	}
+
+	lines := []string{}
+	for _, week := range weeks {
+		line := label("week of "+week.Start.Format("02 Jan")) + bar(float64(week.Completed), highest, width) +
+			fmt.Sprintf(" %d • %s", week.Completed, FormatBytes(week.Bytes))
+		if week.Failed > 0 {
+			line += failedStyle.Render(fmt.Sprintf(" • %d failed", week.Failed))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func rankedView(ranking []Ranked, width int) []string {
+	lines := []string{}
+	for _, entry := range ranking {
+		lines = append(lines, label(entry.Name)+bar(float64(entry.Downloads), float64(ranking[0].Downloads), width)+
+			fmt.Sprintf(" %d • %s", entry.Downloads, FormatBytes(entry.Bytes)))
+	}
+	return lines
+}
+
+func foldersView(folders []Folder, width int) []string {
+	lines := []string{}
+	for _, folder := range folders {
+		line := pathLabel(folder.Path) + bar(float64(folder.Bytes), float64(folders[0].Bytes), width) +
+			fmt.Sprintf(" %s in %d files", FormatBytes(folder.Bytes), folder.Files)
+		if folder.Missing > 0 {
+			line += mutedStyle.Render(fmt.Sprintf(" • %d missing", folder.Missing))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -0,0 +1,240 @@
+// Package stats summarises the download history: how much was downloaded and
+// when, how fast, how often downloads fail, where they came from and how much
+// disk space they take up.
+package stats
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+)
+
+// Default number of days and weeks the activity charts cover.
+const (
+	DefaultDays  = 14
+	DefaultWeeks = 8
+)
+
+// topCount is how many uploaders and sites are ranked.
+const topCount = 5
+
+// Period is the downloads that finished in a day or a week.
+type Period struct {
+	Start     time.Time
+	Completed int
+	Failed    int
+	Bytes     int64
+}
+
+// Ranked is an uploader or site and the completed downloads from it.
+type Ranked struct {
+	Name      string
+	Downloads int
+	Bytes     int64
+}
+
+// Folder is the space the completed downloads saved in a folder take up now.
+// Missing counts the downloads whose files are no longer there.
+type Folder struct {
+	Path    string
+	Files   int
+	Missing int
+	Bytes   int64
+}
+
+// Report is the summary of the download history.
+type Report struct {
+	Completed int
+	Failed    int
+	Bytes     int64
+	// AverageSpeed is in bytes per second, over the completed downloads
+	// whose start and end times are known.
+	AverageSpeed float64
+	Days         []Period
+	Weeks        []Period
+	Uploaders    []Ranked
+	Sites        []Ranked
+	Folders      []Folder
+}
+
+// SuccessRate is the share of finished downloads that completed, from 0 to 1.
+func (r Report) SuccessRate() float64 {
+	if r.Completed+r.Failed == 0 {
+		return 0
+	}
+	return float64(r.Completed) / float64(r.Completed+r.Failed)
+}
+
+// Collect summarises every finished download, with activity charts for the
+// last days and weeks up to now.
+func Collect(now time.Time, days, weeks int) (Report, error) {
+	items, err := data.SearchHistory(data.HistoryFilter{Statuses: []string{"completed", "error"}})
+	if err != nil {
+		return Report{}, err
+	}
+
+	today := startOfDay(now)
+	report := Report{
+		Days:  periods(today.AddDate(0, 0, 1-days), days, 1),
+		Weeks: periods(startOfWeek(today).AddDate(0, 0, 7*(1-weeks)), weeks, 7),
+	}
+
+	var (
+		timedBytes   int64
+		timedSeconds float64
+		uploaders    = map[string]*Ranked{}
+		sites        = map[string]*Ranked{}
+		folders      = map[string]*Folder{}
+	)
+	for _, item := range items {
+		finished := item.CompletedAt
+		if !finished.Valid {
+			finished = item.StartedAt
+		}
+		completed := item.Status == "completed"
+
+		if completed {
+			report.Completed++
+			report.Bytes += item.FileSize
+		} else {
+			report.Failed++
+		}
+		if finished.Valid {
+			count(report.Days, finished.Time, completed, item.FileSize)
+			count(report.Weeks, finished.Time, completed, item.FileSize)
+		}
+		if !completed {
+			continue
+		}
+
+		if item.StartedAt.Valid && item.CompletedAt.Valid && item.FileSize > 0 {
+			if seconds := item.CompletedAt.Time.Sub(item.StartedAt.Time).Seconds(); seconds > 0 {
+				timedBytes += item.FileSize
+				timedSeconds += seconds
+			}
+		}
+		rank(uploaders, item.Uploader, item.FileSize)
+		rank(sites, site(item), item.FileSize)
+		if len(item.FilePath) > 0 {
+			addFile(folders, item.FilePath)
+		}
+	}
+
+	if timedSeconds > 0 {
+		report.AverageSpeed = float64(timedBytes) / timedSeconds
+	}
+	report.Uploaders = top(uploaders)
+	report.Sites = top(sites)
+	for _, folder := range folders {
+		report.Folders = append(report.Folders, *folder)
+	}
+	sort.Slice(report.Folders, func(i, j int) bool {
+		if report.Folders[i].Bytes != report.Folders[j].Bytes {
+			return report.Folders[i].Bytes > report.Folders[j].Bytes
+		}
+		return report.Folders[i].Path < report.Folders[j].Path
+	})
+
+	return report, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Local().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+// startOfWeek returns the Monday of the week day is in.
+func startOfWeek(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// periods returns n empty periods of length days, the first starting at start.
+func periods(start time.Time, n, days int) []Period {
+	result := make([]Period, n)
+	for i := range result {
+		result[i].Start = start.AddDate(0, 0, i*days)
+	}
+	return result
+}
+
+// count adds a download that finished at t to the period it falls in, if any.
+func count(periods []Period, t time.Time, completed bool, bytes int64) {
+	for i := len(periods) - 1; i >= 0; i-- {
+		if t.Before(periods[i].Start) {
+			continue
+		}
+		if i == len(periods)-1 || t.Before(periods[i+1].Start) {
+			if completed {
+				periods[i].Completed++
+				periods[i].Bytes += bytes
+			} else {
+				periods[i].Failed++
+			}
+		}
+		return
+	}
+}
+
+func rank(ranking map[string]*Ranked, name string, bytes int64) {
+	if len(name) == 0 {
+		return
+	}
+	if ranking[name] == nil {
+		ranking[name] = &Ranked{Name: name}
+	}
+	ranking[name].Downloads++
+	ranking[name].Bytes += bytes
+}
+
+// top returns the entries of ranking with the most downloads.
+func top(ranking map[string]*Ranked) []Ranked {
+	result := []Ranked{}
+	for _, entry := range ranking {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Downloads != result[j].Downloads {
+			return result[i].Downloads > result[j].Downloads
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > topCount {
+		result = result[:topCount]
+	}
+	return result
+}
+
+// site names where a download came from, the yt-dlp extractor when it was
+// recorded and otherwise the host of its URL.
+func site(item *data.QueueItem) string {
+	if len(item.Extractor) > 0 {
+		name, _, _ := strings.Cut(strings.ToLower(item.Extractor), ":")
+		return name
+	}
+	for _, address := range []string{item.WebpageUrl, item.VideoId} {
+		if parsed, err := url.Parse(address); err == nil && len(parsed.Hostname()) > 0 {
+			return strings.TrimPrefix(parsed.Hostname(), "www.")
+		}
+	}
+	return ""
+}
+
+// addFile counts a downloaded file towards the folder it was saved in.
+func addFile(folders map[string]*Folder, path string) {
+	dir := filepath.Dir(path)
+	if folders[dir] == nil {
+		folders[dir] = &Folder{Path: dir}
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		folders[dir].Missing++
+		return
+	}
+	folders[dir].Files++
+	folders[dir].Bytes += info.Size()
+}
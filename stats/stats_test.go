@@ -0,0 +1,143 @@
+package stats
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{bytes: 0, want: "0 B"},
+		{bytes: 1023, want: "1023 B"},
+		{bytes: 1024, want: "1.0 KB"},
+		{bytes: 1536, want: "1.5 KB"},
+		{bytes: 5 << 20, want: "5.0 MB"},
+		{bytes: 3 << 30, want: "3.0 GB"},
+		{bytes: 2048 << 40, want: "2048.0 TB"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			if formatted := FormatBytes(test.bytes); formatted != test.want {
+				t.Errorf("formatted as %s, want %s", formatted, test.want)
+			}
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []int
+		want   string
+	}{
+		{values: []int{}, want: ""},
+		{values: []int{0, 0}, want: "▁▁"},
+		{values: []int{0, 1, 8}, want: "▁▂█"},
+		{values: []int{3, 3}, want: "██"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			if line := sparkline(test.values); line != test.want {
+				t.Errorf("drew %q, want %q", line, test.want)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	if err := data.Open(util.SettingsConfig{DatabasePath: filepath.Join(dir, "test.db")}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = data.CloseDatabase() })
+
+	saved := filepath.Join(dir, "saved.mp4")
+	if err := os.WriteFile(saved, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-03-06 is a wednesday
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.Local)
+	at := func(t time.Time) sql.NullTime { return sql.NullTime{Time: t, Valid: true} }
+	items := []data.QueueItem{
+		{
+			VideoId: "https://youtu.be/aaaaaaaaaaa", Status: "completed", Uploader: "alice", Extractor: "Youtube:tab",
+			FileSize: 1000, FilePath: saved, StartedAt: at(now.Add(-time.Hour - 10*time.Second)), CompletedAt: at(now.Add(-time.Hour)),
+		},
+		{
+			VideoId: "https://example.com/b", WebpageUrl: "https://www.vimeo.com/1", Status: "completed", Uploader: "alice",
+			FileSize: 3000, FilePath: filepath.Join(dir, "deleted.mp4"), CompletedAt: at(now.AddDate(0, 0, -3)),
+		},
+		{VideoId: "https://example.com/c", Status: "error", StartedAt: at(now.AddDate(0, 0, -2))},
+		{VideoId: "https://example.com/d", Status: "completed", Uploader: "bob", CompletedAt: at(now.AddDate(0, 0, -60))},
+		{VideoId: "https://example.com/e"},
+	}
+	if err := data.InsertQueueItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Collect(now, 7, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Completed != 3 || report.Failed != 1 || report.Bytes != 4000 {
+		t.Errorf("counted %d completed and %d failed with %d bytes, want 3, 1 and 4000", report.Completed, report.Failed, report.Bytes)
+	}
+	if rate := report.SuccessRate(); rate != 0.75 {
+		t.Errorf("success rate is %v, want 0.75", rate)
+	}
+	// only the first download has both times
+	if report.AverageSpeed != 100 {
+		t.Errorf("average speed is %v, want 100", report.AverageSpeed)
+	}
+
+	days := []string{}
+	for _, day := range report.Days {
+		days = append(days, day.Start.Format("Mon 2")+" "+strings.Repeat("+", day.Completed)+strings.Repeat("-", day.Failed))
+	}
+	wantDays := []string{"Thu 29 ", "Fri 1 ", "Sat 2 ", "Sun 3 +", "Mon 4 -", "Tue 5 ", "Wed 6 +"}
+	if !reflect.DeepEqual(days, wantDays) {
+		t.Errorf("days are %q, want %q", days, wantDays)
+	}
+	weeks := []Period{
+		{Start: time.Date(2024, 2, 26, 0, 0, 0, 0, time.Local), Completed: 1, Bytes: 3000},
+		{Start: time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local), Completed: 1, Failed: 1, Bytes: 1000},
+	}
+	if !reflect.DeepEqual(report.Weeks, weeks) {
+		t.Errorf("weeks are %+v, want %+v", report.Weeks, weeks)
+	}
+
+	uploaders := []Ranked{{Name: "alice", Downloads: 2, Bytes: 4000}, {Name: "bob", Downloads: 1}}
+	if !reflect.DeepEqual(report.Uploaders, uploaders) {
+		t.Errorf("uploaders are %+v, want %+v", report.Uploaders, uploaders)
+	}
+	sites := []Ranked{{Name: "example.com", Downloads: 1}, {Name: "vimeo.com", Downloads: 1, Bytes: 3000}, {Name: "youtube", Downloads: 1, Bytes: 1000}}
+	if !reflect.DeepEqual(report.Sites, sites) {
+		t.Errorf("sites are %+v, want %+v", report.Sites, sites)
+	}
+	folders := []Folder{{Path: dir, Files: 1, Missing: 1, Bytes: 5}}
+	if !reflect.DeepEqual(report.Folders, folders) {
+		t.Errorf("folders are %+v, want %+v", report.Folders, folders)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	rendered := Render(Report{}, 80)
+	for _, want := range []string{"0 completed", "0.0% succeeded", "average speed unknown", "nothing yet"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("rendered\n%s\nwithout %q", rendered, want)
+		}
+	}
+}
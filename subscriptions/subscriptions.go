@@ -0,0 +1,159 @@
+package subscriptions
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// New validates the options of a new subscription.
+func New(cfg util.Config, url, preset, titleRegex string, minDuration int, dateAfter string) (data.Subscription, error) {
+	if len(url) == 0 {
+		return data.Subscription{}, fmt.Errorf("a subscription needs a channel or playlist URL")
+	}
+	if len(preset) > 0 {
+		if _, ok := cfg.Preset(preset); !ok {
+			return data.Subscription{}, fmt.Errorf("unknown preset %q", preset)
+		}
+	}
+	if _, err := regexp.Compile(titleRegex); err != nil {
+		return data.Subscription{}, fmt.Errorf("invalid title regex: %v", err)
+	}
+	if minDuration < 0 {
+		return data.Subscription{}, fmt.Errorf("minimum duration can't be negative")
+	}
+	if len(dateAfter) > 0 && !regexp.MustCompile(`^\d{8}$`).MatchString(dateAfter) {
+		return data.Subscription{}, fmt.Errorf("date %q should be in the form YYYYMMDD", dateAfter)
+	}
+
+	return data.Subscription{
+		Url:         url,
+		Preset:      preset,
+		TitleRegex:  titleRegex,
+		MinDuration: minDuration,
+		DateAfter:   dateAfter,
+	}, nil
+}
+
+// Due reports whether a subscription should be polled again at now.
+func Due(cfg util.Config, subscription *data.Subscription, now time.Time) bool {
+	if !subscription.LastChecked.Valid {
+		return true
+	}
+	interval := time.Duration(cfg.Settings.SubscriptionInterval) * time.Minute
+	return !subscription.LastChecked.Time.Add(interval).After(now)
+}
+
+// Check polls a subscription and queues every entry that hasn't been seen
+// before, passes its filters and isn't already in the queue or history,
+// returning how many were queued. The first check of a subscription without a
+// date filter only remembers what is already there so a channel's back
+// catalogue isn't queued.
+func Check(cfg util.Config, subscription *data.Subscription) (int, error) {
+	var titlePattern *regexp.Regexp
+	if len(subscription.TitleRegex) > 0 {
+		var err error
+		titlePattern, err = regexp.Compile(subscription.TitleRegex)
+		if err != nil {
+			return 0, fmt.Errorf("subscription %d has an invalid title regex: %v", subscription.Id, err)
+		}
+	}
+
+	preset, _ := cfg.Preset(subscription.Preset)
+	seedOnly := !subscription.LastChecked.Valid && len(subscription.DateAfter) == 0
+
+	entries, err := ytdlp.ListPlaylist(subscription.Url)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, entry := range entries {
+		link := entry.Link()
+		videoKey := util.VideoKey(link)
+
+		seen, err := data.IsSubscriptionEntrySeen(subscription.Id, videoKey)
+		if err != nil {
+			return added, err
+		}
+		if seen {
+			continue
+		}
+
+		queue := !seedOnly && matches(subscription, titlePattern, entry)
+		if queue {
+			// videos already added some other way aren't queued twice
+			duplicates, err := data.FindDuplicateQueueItems(link)
+			if err != nil {
+				return added, err
+			}
+			queue = len(duplicates) == 0
+		}
+		if queue {
+			if err := data.InsertPresetQueueItem(link, util.SanitizeFileName(entry.Title), preset); err != nil {
+				return added, err
+			}
+			added++
+		}
+
+		// only marked once queued, so an entry that failed to go in is tried again
+		if err := data.MarkSubscriptionEntrySeen(subscription.Id, videoKey); err != nil {
+			return added, err
+		}
+	}
+
+	return added, data.UpdateSubscriptionLastChecked(subscription.Id, time.Now())
+}
+
+// CheckDue checks every subscription that is due and returns how many items
+// were queued in total.
+func CheckDue(cfg util.Config, now time.Time) (int, error) {
+	subscriptions, err := data.GetAllSubscriptions()
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	var firstErr error
+	for _, subscription := range subscriptions {
+		if !Due(cfg, subscription, now) {
+			continue
+		}
+		n, err := Check(cfg, subscription)
+		added += n
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return added, firstErr
+}
+
+// matches applies a subscription's filters to an entry. Entries missing the
+// information a filter needs are let through, flat playlists often leave out
+// durations and dates.
+func matches(subscription *data.Subscription, titlePattern *regexp.Regexp, entry ytdlp.Entry) bool {
+	if titlePattern != nil && !titlePattern.MatchString(entry.Title) {
+		return false
+	}
+
+	if subscription.MinDuration > 0 && entry.Duration > 0 && entry.Duration < float64(subscription.MinDuration) {
+		return false
+	}
+
+	if len(subscription.DateAfter) > 0 {
+		uploaded := entry.UploadDate
+		if len(uploaded) == 0 && entry.Timestamp > 0 {
+			uploaded = time.Unix(entry.Timestamp, 0).UTC().Format("20060102")
+		}
+		if len(uploaded) > 0 && uploaded < subscription.DateAfter {
+			return false
+		}
+	}
+
+	return true
+}
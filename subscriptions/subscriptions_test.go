@@ -0,0 +1,262 @@
+package subscriptions
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jim-at-jibba/telecharger/data"
+	util "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// stub replaces yt-dlp with a script that prints the listing written by the
+// returned function, one JSON entry per line like --flat-playlist does.
+func stub(t *testing.T) func(entries ...string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the yt-dlp stub is a shell script")
+	}
+
+	dir := t.TempDir()
+	listing := filepath.Join(dir, "listing.jsonl")
+	script := filepath.Join(dir, "yt-dlp")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat '"+listing+"'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := ytdlp.Binary
+	ytdlp.Binary = script
+	t.Cleanup(func() { ytdlp.Binary = binary })
+
+	set := func(entries ...string) {
+		t.Helper()
+		if err := os.WriteFile(listing, []byte(strings.Join(entries, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set()
+	return set
+}
+
+// openDatabase opens an empty database for the test.
+func openDatabase(t *testing.T) util.Config {
+	t.Helper()
+	cfg := util.Config{
+		Settings: util.SettingsConfig{
+			DatabasePath:         filepath.Join(t.TempDir(), "test.db"),
+			SubscriptionInterval: 60,
+		},
+		Presets: []util.PresetConfig{{Name: "audio", AudioOnly: true, AudioFormat: "mp3"}},
+	}
+	if err := data.Open(cfg.Settings); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = data.CloseDatabase() })
+	return cfg
+}
+
+// subscribe adds a subscription and returns it as stored.
+func subscribe(t *testing.T, subscription data.Subscription) *data.Subscription {
+	t.Helper()
+	id, err := data.InsertSubscription(subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return find(t, id)
+}
+
+func find(t *testing.T, id int) *data.Subscription {
+	t.Helper()
+	subscriptions, err := data.GetAllSubscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Id == id {
+			return subscription
+		}
+	}
+	t.Fatalf("subscription %d not found", id)
+	return nil
+}
+
+// queuedNames returns the output names of the queued items.
+func queuedNames(t *testing.T) []string {
+	t.Helper()
+	items, err := data.GetAllQueueItems("queued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.OutputName)
+	}
+	return names
+}
+
+func TestCheckSeedsFirstCheck(t *testing.T) {
+	setListing := stub(t)
+	cfg := openDatabase(t)
+	subscription := subscribe(t, data.Subscription{Url: "https://www.youtube.com/@channel", Preset: "audio"})
+
+	setListing(
+		`{"id": "aaaaaaaaaa1", "ie_key": "Youtube", "title": "Old one"}`,
+		`{"id": "aaaaaaaaaa2", "ie_key": "Youtube", "title": "Old two"}`,
+	)
+	added, err := Check(cfg, subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 0 {
+		t.Errorf("first check queued %d items, want 0", added)
+	}
+
+	subscription = find(t, subscription.Id)
+	if !subscription.LastChecked.Valid {
+		t.Fatal("first check didn't record when it ran")
+	}
+
+	setListing(
+		`{"id": "aaaaaaaaaa3", "ie_key": "Youtube", "title": "New one"}`,
+		`{"id": "aaaaaaaaaa1", "ie_key": "Youtube", "title": "Old one"}`,
+		`{"id": "aaaaaaaaaa2", "ie_key": "Youtube", "title": "Old two"}`,
+	)
+	added, err = Check(cfg, subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Errorf("second check queued %d items, want 1", added)
+	}
+	if names := queuedNames(t); len(names) != 1 || names[0] != "New one" {
+		t.Errorf("queued %v, want [New one]", names)
+	}
+
+	items, _ := data.GetAllQueueItems("queued")
+	if items[0].Preset != "audio" || !items[0].Options.Bool(ytdlp.AudioOnly) || items[0].AudioFormat != "mp3" {
+		t.Errorf("queued item doesn't use the subscription's preset: %+v", items[0])
+	}
+}
+
+func TestCheckFilters(t *testing.T) {
+	setListing := stub(t)
+	cfg := openDatabase(t)
+	// a date filter means the first check queues rather than seeds
+	subscription := subscribe(t, data.Subscription{
+		Url:         "https://www.youtube.com/playlist?list=PL1",
+		TitleRegex:  "(?i)^episode",
+		MinDuration: 600,
+		DateAfter:   "20240101",
+	})
+
+	setListing(
+		`{"id": "bbbbbbbbbb1", "ie_key": "Youtube", "title": "Episode 1", "duration": 1200, "upload_date": "20240301"}`,
+		`{"id": "bbbbbbbbbb2", "ie_key": "Youtube", "title": "Trailer", "duration": 1200, "upload_date": "20240301"}`,
+		`{"id": "bbbbbbbbbb3", "ie_key": "Youtube", "title": "Episode 2 clip", "duration": 90, "upload_date": "20240301"}`,
+		`{"id": "bbbbbbbbbb4", "ie_key": "Youtube", "title": "Episode 0", "duration": 1200, "upload_date": "20231231"}`,
+		`{"id": "bbbbbbbbbb5", "ie_key": "Youtube", "title": "Episode 3", "timestamp": 1717200000}`,
+	)
+	added, err := Check(cfg, subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2 {
+		t.Errorf("queued %d items, want 2", added)
+	}
+	names := queuedNames(t)
+	if strings.Join(names, ",") != "Episode 1,Episode 3" {
+		t.Errorf("queued %v, want [Episode 1 Episode 3]", names)
+	}
+}
+
+func TestCheckSkipsSeenEntries(t *testing.T) {
+	setListing := stub(t)
+	cfg := openDatabase(t)
+	subscription := subscribe(t, data.Subscription{Url: "https://www.youtube.com/@channel", DateAfter: "20000101"})
+
+	setListing(`{"id": "cccccccccc1", "ie_key": "Youtube", "title": "Video"}`)
+	if added, err := Check(cfg, subscription); err != nil || added != 1 {
+		t.Fatalf("first check queued %d items (%v), want 1", added, err)
+	}
+
+	// deleting the item doesn't make the subscription queue it again
+	items, _ := data.GetAllQueueItems("queued")
+	if err := data.DeleteQueueItem(items[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := Check(cfg, find(t, subscription.Id)); err != nil || added != 0 {
+		t.Errorf("second check queued %d items (%v), want 0", added, err)
+	}
+}
+
+func TestCheckSkipsDuplicates(t *testing.T) {
+	setListing := stub(t)
+	cfg := openDatabase(t)
+	subscription := subscribe(t, data.Subscription{Url: "https://www.youtube.com/@channel", DateAfter: "20000101"})
+
+	if err := data.InsertQueueItem(data.QueueItem{VideoId: "https://youtu.be/dddddddddd1", OutputName: "added by hand"}); err != nil {
+		t.Fatal(err)
+	}
+
+	setListing(
+		`{"id": "dddddddddd1", "ie_key": "Youtube", "title": "Already queued"}`,
+		`{"id": "dddddddddd2", "ie_key": "Youtube", "title": "New"}`,
+	)
+	added, err := Check(cfg, subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Errorf("queued %d items, want 1", added)
+	}
+	if names := queuedNames(t); strings.Join(names, ",") != "added by hand,New" {
+		t.Errorf("queued %v, want [added by hand New]", names)
+	}
+}
+
+func TestCheckDue(t *testing.T) {
+	setListing := stub(t)
+	cfg := openDatabase(t)
+	now := time.Now()
+
+	due := subscribe(t, data.Subscription{Url: "https://www.youtube.com/@due", DateAfter: "20000101"})
+	recent := subscribe(t, data.Subscription{Url: "https://www.youtube.com/@recent", DateAfter: "20000101"})
+	checked := now.Add(-10 * time.Minute)
+	if err := data.UpdateSubscriptionLastChecked(recent.Id, checked); err != nil {
+		t.Fatal(err)
+	}
+
+	setListing(
+		`{"id": "eeeeeeeeee1", "ie_key": "Youtube", "title": "One"}`,
+		`{"id": "eeeeeeeeee2", "ie_key": "Youtube", "title": "Two"}`,
+	)
+	added, err := CheckDue(cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2 {
+		t.Errorf("queued %d items, want 2", added)
+	}
+	if !find(t, due.Id).LastChecked.Valid {
+		t.Error("the due subscription wasn't checked")
+	}
+	if last := find(t, recent.Id).LastChecked.Time; !last.Equal(checked) {
+		t.Errorf("the subscription checked 10 minutes ago was checked again at %v", last)
+	}
+
+	// once the interval has passed it is due too, but has nothing new to add
+	added, err = CheckDue(cfg, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 0 {
+		t.Errorf("queued %d items after the interval, want 0", added)
+	}
+	if last := find(t, recent.Id).LastChecked.Time; last.Equal(checked) {
+		t.Error("the subscription wasn't checked once its interval had passed")
+	}
+}
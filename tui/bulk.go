@@ -0,0 +1,317 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jim-at-jibba/telecharger/data"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// confirmation is a yes/no question shown with the dialog view before an
+// action is carried out.
+type confirmation struct {
+	question string
+	action   func() tea.Cmd
+}
+
+// bulkAction is an action that can be run against every selected item in the
+// focused list.
+type bulkAction struct {
+	name string
+	run  func(m *model, items []QueueItem) tea.Cmd
+}
+
+var (
+	startAction = bulkAction{
+		name: "Start downloads",
+		run: func(m *model, items []QueueItem) tea.Cmd {
+			for _, item := range items {
+				m.batch = append(m.batch, item.id)
+			}
+			return m.startNextInBatch()
+		},
+	}
+	deleteAction = bulkAction{
+		name: "Delete",
+		run: func(m *model, items []QueueItem) tea.Cmd {
+			for _, item := range items {
+				// the item being downloaded can't be removed from under yt-dlp
+				if item.status == "downloading" {
+					continue
+				}
+				data.DeleteQueueItem(item.id)
+			}
+			return nil
+		},
+	}
+	retryAction = bulkAction{
+		name: "Retry failed downloads",
+		run: func(m *model, items []QueueItem) tea.Cmd {
+			for _, item := range items {
+				if item.status == "error" {
+					_ = data.RequeueQueueItem(item.id)
+				}
+			}
+			return nil
+		},
+	}
+	requeueAction = bulkAction{
+		name: "Re-queue",
+		run: func(m *model, items []QueueItem) tea.Cmd {
+			for _, item := range items {
+				// forget the download so yt-dlp doesn't skip it as already done
+				_ = utils.RemoveFromArchive(item.preset, utils.VideoKey(item.videoId))
+				_ = data.RequeueQueueItem(item.id)
+			}
+			return nil
+		},
+	}
+	moveTopAction = bulkAction{
+		name: "Move to top",
+		run: func(m *model, items []QueueItem) tea.Cmd {
+			// moving in reverse keeps the selected items in their current order
+			for i := len(items) - 1; i >= 0; i-- {
+				_ = data.MoveQueueItemToTop(items[i].id)
+			}
+			return nil
+		},
+	}
+	changePresetAction = bulkAction{
+		name: "Change preset",
+	}
+)
+
+// bulkActions returns the actions that make sense for the focused list.
+func (m model) bulkActions() []bulkAction {
+	switch m.focused {
+	case queued:
+		return []bulkAction{startAction, moveTopAction, changePresetAction, exportAction, deleteAction}
+	case done:
+		return []bulkAction{requeueAction, changePresetAction, exportAction, deleteAction}
+	default:
+		return []bulkAction{retryAction, exportAction, deleteAction}
+	}
+}
+
+// selectedItems returns the selected items of the focused list in list order.
+func (m model) selectedItems() []QueueItem {
+	items := []QueueItem{}
+	if len(m.lists) == 0 {
+		return items
+	}
+	for _, listItem := range m.lists[m.focused].Items() {
+		item := listItem.(QueueItem)
+		if m.selected[item.id] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// toggleSelected flips the selection of the item under the cursor.
+func (m *model) toggleSelected() tea.Cmd {
+	if len(m.lists[m.focused].Items()) == 0 {
+		return nil
+	}
+	item := m.lists[m.focused].SelectedItem().(QueueItem)
+	m.selected[item.id] = !m.selected[item.id]
+	item.selected = m.selected[item.id]
+	return m.lists[m.focused].SetItem(m.lists[m.focused].Index(), item)
+}
+
+// toggleSelectAll selects every item currently visible in the focused list,
+// which is the filtered set when a filter is applied. When they are all
+// selected already they are deselected instead.
+func (m *model) toggleSelectAll() tea.Cmd {
+	visible := map[int]bool{}
+	allSelected := true
+	for _, listItem := range m.lists[m.focused].VisibleItems() {
+		item := listItem.(QueueItem)
+		visible[item.id] = true
+		if !m.selected[item.id] {
+			allSelected = false
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i, listItem := range m.lists[m.focused].Items() {
+		item := listItem.(QueueItem)
+		if !visible[item.id] {
+			continue
+		}
+		m.selected[item.id] = !allSelected
+		item.selected = !allSelected
+		cmds = append(cmds, m.lists[m.focused].SetItem(i, item))
+	}
+	return tea.Batch(cmds...)
+}
+
+// startNextInBatch starts the next item of a bulk start that is still queued.
+func (m *model) startNextInBatch() tea.Cmd {
+	if m.downloading || !m.checkDiskSpace() {
+		return nil
+	}
+	for len(m.batch) > 0 {
+		id := m.batch[0]
+		m.batch = m.batch[1:]
+		item, err := data.GetQueueItem(id)
+		if err != nil || item.Status != "queued" {
+			continue
+		}
+		return m.startDownload(newQueueItemFromData(item))
+	}
+	return nil
+}
+
+// changePreset applies the named preset's options to the items.
+func (m *model) changePreset(items []QueueItem, name string) {
+	preset, ok := m.appConfig.Preset(name)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		_ = data.UpdateQueueItemPreset(item.id, preset, item.options)
+	}
+}
+
+// updateBulk handles keys while the bulk action menu, preset picker or a
+// confirmation dialog is open.
+func (m *model) updateBulk(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.selectedItems()
+
+	switch {
+	case m.confirm != nil:
+		switch {
+		case key.Matches(msg, DefaultKeyMap.Left):
+			m.PrevDialogChoice()
+		case key.Matches(msg, DefaultKeyMap.Right):
+			m.NextDialogChoice()
+		case key.Matches(msg, DefaultKeyMap.Back):
+			m.confirm = nil
+		case key.Matches(msg, DefaultKeyMap.Enter):
+			confirm := m.confirm
+			m.confirm = nil
+			if m.dialogChoice != yes {
+				return m, nil
+			}
+			cmd := confirm.action()
+			m.selected = map[int]bool{}
+			m.initLists(m.width, m.height)
+			return m, cmd
+		}
+	case m.presetPicker:
+		switch {
+		case key.Matches(msg, DefaultKeyMap.Up):
+			if m.presetChoice > 0 {
+				m.presetChoice--
+			}
+		case key.Matches(msg, DefaultKeyMap.Down):
+			if m.presetChoice < len(m.appConfig.Presets)-1 {
+				m.presetChoice++
+			}
+		case key.Matches(msg, DefaultKeyMap.Back):
+			m.presetPicker = false
+		case key.Matches(msg, DefaultKeyMap.Enter):
+			m.presetPicker = false
+			if len(m.appConfig.Presets) == 0 {
+				return m, nil
+			}
+			name := m.appConfig.Presets[m.presetChoice].Name
+			m.dialogChoice = yes
+			m.confirm = &confirmation{
+				question: fmt.Sprintf("Change the preset of %d selected items to %q?", len(items), name),
+				action: func() tea.Cmd {
+					m.changePreset(items, name)
+					return nil
+				},
+			}
+		}
+	case m.bulkMenu:
+		actions := m.bulkActions()
+		switch {
+		case key.Matches(msg, DefaultKeyMap.Up):
+			if m.bulkChoice > 0 {
+				m.bulkChoice--
+			}
+		case key.Matches(msg, DefaultKeyMap.Down):
+			if m.bulkChoice < len(actions)-1 {
+				m.bulkChoice++
+			}
+		case key.Matches(msg, DefaultKeyMap.Back):
+			m.bulkMenu = false
+		case key.Matches(msg, DefaultKeyMap.Enter):
+			m.bulkMenu = false
+			action := actions[m.bulkChoice]
+			if action.name == changePresetAction.name {
+				m.presetPicker = true
+				m.presetChoice = 0
+				return m, nil
+			}
+			m.dialogChoice = yes
+			m.confirm = &confirmation{
+				question: fmt.Sprintf("%s %d selected items?", action.name, len(items)),
+				action: func() tea.Cmd {
+					return action.run(m, items)
+				},
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// bulkMenuView renders the bulk action menu or preset picker in the same style
+// as the dialog view.
+func (m model) bulkMenuView() string {
+	var (
+		title   string
+		options []string
+		choice  int
+	)
+
+	if m.presetPicker {
+		title = "Choose a preset"
+		for _, preset := range m.appConfig.Presets {
+			options = append(options, preset.Name)
+		}
+		if len(options) == 0 {
+			options = append(options, "No presets configured")
+		}
+		choice = m.presetChoice
+	} else {
+		title = fmt.Sprintf("Bulk actions for %d selected items", len(m.selectedItems()))
+		for _, action := range m.bulkActions() {
+			options = append(options, action.name)
+		}
+		choice = m.bulkChoice
+	}
+
+	return m.pickerView(title, options, choice)
+}
+
+// pickerView shows a dialog for choosing one of options.
+func (m model) pickerView(title string, options []string, choice int) string {
+	rows := []string{}
+	for i, option := range options {
+		if i == choice {
+			rows = append(rows, ActiveStyle.Render("> "+option))
+		} else {
+			rows = append(rows, InactiveStyle.Render("  "+option))
+		}
+	}
+
+	ui := lipgloss.JoinVertical(lipgloss.Center,
+		lipgloss.NewStyle().Width(50).Align(lipgloss.Center).Render(title),
+		lipgloss.NewStyle().Width(50).MarginTop(1).Render(strings.Join(rows, "\n")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("241")).MarginTop(1).Render("↑/↓: choose • enter: select • esc: cancel"),
+	)
+
+	return lipgloss.Place(m.width, len(options)+9,
+		lipgloss.Center, lipgloss.Center,
+		DialogBoxStyle.Render(ui),
+	)
+}
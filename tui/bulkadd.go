@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jim-at-jibba/telecharger/data"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// maxBulkSummaryLines is how many lines of the bulk add summary are shown.
+const maxBulkSummaryLines = 8
+
+// bulkLine is the outcome of checking one URL, or one line without a URL, of
+// the text pasted into the bulk add field.
+type bulkLine struct {
+	line    int
+	url     string
+	text    string
+	problem string
+}
+
+// queueItemsAdded reports how many items were added at once.
+type queueItemsAdded struct {
+	added int
+}
+
+func newBulkTextarea() textarea.Model {
+	urls := textarea.New()
+	urls.Placeholder = "Paste URLs, one per line, or any text containing links"
+	urls.ShowLineNumbers = true
+	urls.CharLimit = 0
+	urls.SetWidth(80)
+	urls.SetHeight(8)
+	return urls
+}
+
+// updateBulkText passes a key to the list of URLs. Pasted text is inserted
+// here since the textarea would put all of its lines on one.
+func (m *FormModel) updateBulkText(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case msg.Type == tea.KeyRunes && strings.ContainsAny(string(msg.Runes), "\r\n"):
+		m.pasteBulkText(string(msg.Runes))
+	case msg.Type == tea.KeyCtrlV:
+		if text, err := clipboard.ReadAll(); err == nil {
+			m.pasteBulkText(text)
+		}
+	default:
+		m.urls, cmd = m.urls.Update(msg)
+	}
+	m.bulkLines = checkBulkText(m.urls.Value(), m.knownVideos)
+	return cmd
+}
+
+func (m *FormModel) pasteBulkText(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	m.urls.InsertString(strings.ReplaceAll(text, "\r", "\n"))
+}
+
+// checkBulkText finds the URLs in text, marking lines without one, repeats of
+// an earlier line and videos that are already in the queue or history, known
+// by the status of each video key.
+func checkBulkText(text string, known map[string]string) []bulkLine {
+	lines := []bulkLine{}
+	seen := map[string]int{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		urls := utils.ExtractURLs(line)
+		if _, ok := utils.YoutubeID(line); ok && len(urls) == 0 {
+			urls = []string{line}
+		}
+		if len(urls) == 0 {
+			lines = append(lines, bulkLine{line: i + 1, text: line, problem: "no URL found"})
+			continue
+		}
+
+		for _, url := range urls {
+			canonical, err := utils.CanonicalURL(url)
+			checked := bulkLine{line: i + 1, url: canonical, text: url}
+			key := utils.VideoKey(url)
+			if err != nil {
+				checked.problem = err.Error()
+			} else if first, ok := seen[key]; ok {
+				checked.problem = fmt.Sprintf("repeats line %d", first)
+			} else if status, ok := known[key]; ok {
+				checked.problem = fmt.Sprintf("already added, %s", status)
+			}
+			if _, ok := seen[key]; !ok {
+				seen[key] = i + 1
+			}
+			lines = append(lines, checked)
+		}
+	}
+	return lines
+}
+
+// bulkURLs returns the URLs that will be added.
+func (m FormModel) bulkURLs() []string {
+	urls := []string{}
+	for _, line := range m.bulkLines {
+		if len(line.problem) == 0 {
+			urls = append(urls, line.url)
+		}
+	}
+	return urls
+}
+
+// toggleBulk switches between adding one URL and pasting many, carrying over
+// a URL that was already typed.
+func (m FormModel) toggleBulk() (tea.Model, tea.Cmd) {
+	m.bulk = !m.bulk
+	m.duplicateWarning = ""
+	m.errorMessage = ""
+	if !m.bulk {
+		m.urls.Blur()
+		return m, m.videoId.Focus()
+	}
+
+	m.videoId.Blur()
+	if url := strings.TrimSpace(m.videoId.Value()); len(url) > 0 && len(strings.TrimSpace(m.urls.Value())) == 0 {
+		m.urls.SetValue(url + "\n")
+	}
+	// loaded once here rather than looked up for every URL on each key press
+	m.knownVideos, _ = data.GetVideoKeyStatuses()
+	m.bulkLines = checkBulkText(m.urls.Value(), m.knownVideos)
+	return m, m.urls.Focus()
+}
+
+// CreateQueuedItems adds every valid URL of the bulk add field with the
+// form's options in a single transaction, returning how many were added.
+func (m FormModel) CreateQueuedItems() (int, error) {
+	items := []data.QueueItem{}
+	for _, url := range m.bulkURLs() {
+		items = append(items, m.queueItem(url, ""))
+	}
+
+	if err := data.InsertQueueItems(items); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// bulkSummaryView lists the outcome of each pasted line, showing the problems
+// first since those are the lines that need attention.
+func (m FormModel) bulkSummaryView() string {
+	if len(m.bulkLines) == 0 {
+		return InactiveStyle.Render("No URLs yet")
+	}
+
+	problems, valid := []string{}, []string{}
+	for _, line := range m.bulkLines {
+		if len(line.problem) > 0 {
+			problems = append(problems, WarningStyle.Render(fmt.Sprintf("✗ %3d  %s: %s", line.line, line.text, line.problem)))
+		} else {
+			valid = append(valid, CheckboxCheckedStyle.Render(fmt.Sprintf("✓ %3d  %s", line.line, line.text)))
+		}
+	}
+
+	rows := append(problems, valid...)
+	if len(rows) > maxBulkSummaryLines {
+		more := len(rows) - maxBulkSummaryLines
+		rows = append(rows[:maxBulkSummaryLines], InactiveStyle.Render(fmt.Sprintf("… and %d more", more)))
+	}
+
+	header := fmt.Sprintf("%d to add, %d skipped", len(valid), len(problems))
+	return strings.Join(append([]string{header}, rows...), "\n")
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jim-at-jibba/telecharger/data"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+// clipboardInterval is how often the clipboard is read while it is watched.
+const clipboardInterval = time.Second
+
+// clipboardTickMsg is sent every clipboardInterval to read the clipboard when
+// watch_clipboard is on.
+type clipboardTickMsg time.Time
+
+// clipboardRead carries the text on the clipboard.
+type clipboardRead struct {
+	text string
+	err  error
+}
+
+func clipboardTick() tea.Cmd {
+	return tea.Tick(clipboardInterval, func(t time.Time) tea.Msg {
+		return clipboardTickMsg(t)
+	})
+}
+
+func readClipboard() tea.Msg {
+	text, err := clipboard.ReadAll()
+	return clipboardRead{text: text, err: err}
+}
+
+// clipboardURL returns the canonical form of the URL on the clipboard when it
+// holds a single link and nothing else.
+func clipboardURL(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	urls := utils.ExtractURLs(text)
+	if len(urls) != 1 || urls[0] != text {
+		return "", false
+	}
+	canonical, err := utils.CanonicalURL(text)
+	return canonical, err == nil
+}
+
+// checkClipboard offers a newly copied URL for download unless it is queued
+// already or was dismissed before.
+func (m *model) checkClipboard(msg clipboardRead) {
+	if msg.err != nil {
+		// only the first failure is shown, the clipboard tool is usually missing
+		if !m.clipboardFailed {
+			m.clipboardFailed = true
+			m.transferStatus = WarningStyle.Render(fmt.Sprintf("can't read the clipboard: %v", msg.err))
+		}
+		return
+	}
+	m.clipboardFailed = false
+	if msg.text == m.clipboardText {
+		return
+	}
+	m.clipboardText = msg.text
+
+	url, ok := clipboardURL(msg.text)
+	if !ok {
+		return
+	}
+	if dismissed, err := data.IsURLDismissed(url); err != nil || dismissed {
+		return
+	}
+	if duplicates, err := data.FindDuplicateQueueItems(url); err != nil || len(duplicates) > 0 {
+		return
+	}
+	m.clipboardOffer = url
+}
+
+// updateClipboardOffer queues or dismisses the offered URL. Other keys are left
+// to the dashboard so the offer never gets in the way.
+func (m *model) updateClipboardOffer(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, DefaultKeyMap.QueueCopied):
+		url := m.clipboardOffer
+		m.clipboardOffer = ""
+		preset, _ := m.appConfig.Preset(m.appConfig.Settings.DefaultPreset)
+		if err := data.InsertPresetQueueItem(url, "", preset); err != nil {
+			m.transferStatus = WarningStyle.Render(fmt.Sprintf("can't queue %s: %v", url, err))
+			return nil, true
+		}
+		m.transferStatus = fmt.Sprintf("queued %s", url)
+		m.initLists(m.width, m.height)
+		if m.autoStart {
+			return m.startNextDownload(), true
+		}
+		return nil, true
+	case key.Matches(msg, DefaultKeyMap.DismissCopied):
+		if err := data.DismissURL(m.clipboardOffer); err != nil {
+			m.transferStatus = WarningStyle.Render(err.Error())
+		}
+		m.clipboardOffer = ""
+		return nil, true
+	}
+	return nil, false
+}
+
+// clipboardOfferView is the prompt for a copied URL, shown above the help.
+func (m model) clipboardOfferView() string {
+	preset := m.appConfig.Settings.DefaultPreset
+	if len(preset) == 0 {
+		preset = "no preset"
+	}
+	return ActiveStyle.Render(fmt.Sprintf(" Copied %s • y: queue with %s • n: don't ask again", m.clipboardOffer, preset))
+}
@@ -2,25 +2,38 @@ package tui
 
 import (
 	"bufio"
+	"bytes"
+	"database/sql"
 	"fmt"
 	"io"
+	"log"
 	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/diskspace"
+	"github.com/jim-at-jibba/telecharger/feeds"
+	"github.com/jim-at-jibba/telecharger/rate"
+	"github.com/jim-at-jibba/telecharger/ratelimit"
+	"github.com/jim-at-jibba/telecharger/schedule"
+	"github.com/jim-at-jibba/telecharger/subscriptions"
 	utils "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
 )
 
 const (
@@ -40,11 +53,48 @@ const (
 /* MODEL MANAGMENT */
 var Models []tea.Model
 
+// NewModels returns the screens for cfg in the order of the constants below,
+// the dashboard first.
+func NewModels(cfg utils.Config, width, height int) []tea.Model {
+	return []tea.Model{
+		InitialModel(cfg),
+		NewForm(cfg),
+		NewSubscriptions(cfg, width, height),
+		NewSettings(cfg, width, height),
+		NewHistory(cfg, width, height),
+		NewStats(cfg, width, height),
+	}
+}
+
 const (
 	Info status = iota
 	Form
+	Subscriptions
+	Settings
+	History
+	Stats
 )
 
+// listsChanged tells the dashboard to reload its lists after another screen
+// changed the queue.
+type listsChanged struct{}
+
+// forwardToDashboard passes a message that isn't a key press to the dashboard
+// while another screen is showing, so downloads and background checks keep
+// being tracked.
+func forwardToDashboard(msg tea.Msg) tea.Cmd {
+	if msg == nil {
+		return nil
+	}
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return nil
+	}
+
+	var cmd tea.Cmd
+	Models[Info], cmd = Models[Info].Update(msg)
+	return cmd
+}
+
 const (
 	yes status = iota
 	no
@@ -56,18 +106,67 @@ var version = "0.2.1"
 type errMsg error
 
 type QueueItem struct {
-	id             int
-	videoId        string
-	outputName     string
-	embedThumbnail bool
-	audioOnly      bool
-	audioFormat    string
-	extraCommands  string
+	id            int
+	videoId       string
+	outputName    string
+	audioFormat   string
+	extraCommands string
+	priority      int
+	preset        string
+	status        string
+	notBefore     sql.NullTime
+	rateLimit     string
+	options       ytdlp.Options
+	tags          []string
+	errorMessage  string
+	selected      bool
 }
 
-func (i QueueItem) Title() string       { return i.outputName }
-func (i QueueItem) Description() string { return i.videoId }
-func (i QueueItem) FilterValue() string { return i.outputName }
+func (i QueueItem) Title() string {
+	if i.selected {
+		return "● " + i.name()
+	}
+	return i.name()
+}
+func (i QueueItem) Description() string {
+	parts := []string{i.videoId}
+	if len(i.errorMessage) > 0 {
+		parts = append(parts, i.errorMessage)
+	}
+	if len(i.tags) > 0 {
+		parts = append(parts, i.tagsView())
+	}
+	return strings.Join(parts, " • ")
+}
+func (i QueueItem) FilterValue() string { return i.name() + " " + i.tagsView() }
+
+// tagsView lists the tags of the item, each with a leading #.
+func (i QueueItem) tagsView() string {
+	tags := []string{}
+	for _, tag := range i.tags {
+		tags = append(tags, "#"+tag)
+	}
+	return strings.Join(tags, " ")
+}
+
+// hasTag reports whether the item has tag, in any case.
+func (i QueueItem) hasTag(tag string) bool {
+	for _, t := range i.tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// name is the output name of the item, or its URL for items imported without
+// one, which yt-dlp names itself.
+func (i QueueItem) name() string {
+	if len(i.outputName) == 0 {
+		return i.videoId
+	}
+	return i.outputName
+}
 
 type model struct {
 	width, height int
@@ -76,11 +175,11 @@ type model struct {
 	focused          status
 	queueItemDetails QueueItem
 	doneItemDetails  QueueItem
-	downloadOutput   string
 	currentDownload  QueueItem
 	progress         progress.Model
 	viewport         viewport.Model
 	downloading      bool
+	autoStart        bool
 	spinner          spinner.Model
 	quitting         bool
 	err              error
@@ -88,24 +187,153 @@ type model struct {
 	blockExit        bool
 	dialogChoice     status
 	appConfig        utils.Config
+	selected         map[int]bool
+	batch            []int
+	bulkMenu         bool
+	bulkChoice       int
+	presetPicker     bool
+	presetChoice     int
+	confirm          *confirmation
+	downloadLog      []string
+	logViewer        bool
+	logViewerItem    QueueItem
+	logViewport      viewport.Model
+
+	checkingSources bool
+	sourcesStatus   string
+	schedule        schedule.Schedule
+
+	profilePicker bool
+	profileChoice int
+	profiles      []string
+	profileStatus string
+
+	tagPicker bool
+	tagChoice int
+	tags      []string
+	tagFilter string
+
+	doneLoaded    int
+	doneTotal     int
+	historyStatus string
+
+	diskFree   int64
+	diskNeeded int64
+	diskStatus string
+
+	importPrompt   bool
+	importPath     textinput.Model
+	transferStatus string
+
+	clipboardText   string
+	clipboardOffer  string
+	clipboardFailed bool
+
+	rateLimits        ratelimit.Limits
+	rateLimitOverride int64
+	rateLimitAdjusted bool
+
+	// worked out by refreshLimits rather than on every render
+	scheduleStatus string
+	rateLimitNow   int64
 }
 
 type downloadFinished struct {
 	finished bool
+	// noSpace says why the download stopped for lack of disk space, and
+	// needed is the space to wait for before starting another.
+	noSpace string
+	needed  int64
 }
 
 type downloadingStatusUpdate struct {
 	content string
 }
 
-func NewQueuedItem(videoId, outputName, audioFormat, extraCommands string, embedThumbnail, audioOnly bool) QueueItem {
+// downloadLogLine is a single line of yt-dlp output for the item with id.
+type downloadLogLine struct {
+	id      int
+	content string
+}
+
+// sourceTickMsg is sent every minute to poll the subscriptions and feeds that are due.
+type sourceTickMsg time.Time
+
+// sourcesChecked reports the result of polling subscriptions and feeds.
+type sourcesChecked struct {
+	added int
+	err   error
+}
+
+func sourceTick() tea.Cmd {
+	return tea.Tick(time.Minute, func(t time.Time) tea.Msg {
+		return sourceTickMsg(t)
+	})
+}
+
+// checkSources polls every subscription and feed that is due in the background.
+func (m *model) checkSources() tea.Cmd {
+	settings := m.appConfig.Settings
+	if m.checkingSources || (settings.SubscriptionInterval <= 0 && settings.FeedInterval <= 0) {
+		return nil
+	}
+	m.checkingSources = true
+	cfg := m.appConfig
+	return func() tea.Msg {
+		var (
+			added int
+			err   error
+		)
+		if cfg.Settings.SubscriptionInterval > 0 {
+			added, err = subscriptions.CheckDue(cfg, time.Now())
+		}
+		if cfg.Settings.FeedInterval > 0 {
+			feedsAdded, feedsErr := feeds.CheckDue(cfg, time.Now())
+			added += feedsAdded
+			if err == nil {
+				err = feedsErr
+			}
+		}
+		return sourcesChecked{added: added, err: err}
+	}
+}
+
+// maxLogLines is how many lines of the running download are kept for the log pane.
+const maxLogLines = 500
+
+var progressPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)%`)
+
+// scanLogLines splits yt-dlp output into lines, treating the carriage returns
+// it uses to redraw progress as line endings too.
+func scanLogLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[0:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// newQueueItemFromData converts a database row into a list item.
+func newQueueItemFromData(item *data.QueueItem) QueueItem {
 	return QueueItem{
-		videoId:        videoId,
-		outputName:     outputName,
-		embedThumbnail: embedThumbnail,
-		audioOnly:      audioOnly,
-		audioFormat:    audioFormat,
-		extraCommands:  extraCommands,
+		id:            item.Id,
+		videoId:       item.VideoId,
+		outputName:    item.OutputName,
+		audioFormat:   item.AudioFormat,
+		extraCommands: item.ExtraCommands,
+		priority:      item.Priority,
+		preset:        item.Preset,
+		status:        item.Status,
+		notBefore:     item.NotBefore,
+		rateLimit:     item.RateLimit,
+		options:       item.Options,
+		tags:          item.Tags,
+		errorMessage:  item.ErrorMessage,
 	}
 }
 func notifyMe(item QueueItem) {
@@ -121,12 +349,35 @@ func notifyMe(item QueueItem) {
 		fmt.Printf("%s.\n", os)
 	}
 }
+
+// globalRateLimit is the limit for downloads without their own, either the
+// one set from the dashboard or the configured one for the current time.
+func (m model) globalRateLimit() int64 {
+	if m.rateLimitAdjusted {
+		return m.rateLimitOverride
+	}
+	return m.rateLimits.At(time.Now())
+}
+
+// downloadRateLimit is the limit passed to yt-dlp for item. An item's own limit
+// wins, otherwise it gets the global limit at the time it starts, which it
+// keeps until it finishes.
+func (m model) downloadRateLimit(item QueueItem) int64 {
+	if len(item.rateLimit) > 0 {
+		if limit, err := rate.Parse(item.rateLimit); err == nil {
+			return limit
+		}
+	}
+	return m.globalRateLimit()
+}
+
 func (m model) executeDownload(item QueueItem) tea.Cmd {
+	rateLimit := m.downloadRateLimit(item)
+	minFree := minFreeSpace(m.appConfig.Settings)
 	return func() tea.Msg {
-		args := []string{}
+		args := item.options.Args()
 
-		if item.audioOnly {
-			args = append(args, "-x")
+		if item.options.Bool(ytdlp.AudioOnly) {
 			args = append(args, "--audio-format")
 			if len(item.audioFormat) > 0 {
 				args = append(args, item.audioFormat)
@@ -140,29 +391,128 @@ func (m model) executeDownload(item QueueItem) tea.Cmd {
 			args = append(args, s...)
 		}
 
-		if item.embedThumbnail {
-			args = append(args, "--embed-thumbnail")
+		if rateLimit > 0 {
+			args = append(args, "--limit-rate", strconv.FormatInt(rateLimit, 10))
 		}
 
+		network := m.appConfig.Network(item.preset).Args(item.videoId)
+		args = append(args, network...)
+		// the size probe leaves out the extra commands, which could download
+		// or run something
+		probeArgs := append(item.options.Args(), network...)
+
+		if m.appConfig.Settings.DownloadArchive {
+			if archive, err := utils.ArchivePath(item.preset); err == nil {
+				args = append(args, "--download-archive", archive)
+			}
+		}
+
+		// tagged downloads go to a folder named after their first tag, unnamed
+		// ones keep yt-dlp's default name there
+		folder := m.appConfig.Settings.DownloadFolder
+		tagFolder := m.appConfig.Settings.TagFolders && len(item.tags) > 0
+		if tagFolder {
+			folder = filepath.Join(folder, item.tags[0])
+		}
 		if len(item.outputName) > 0 {
 			args = append(args, "-o")
-			args = append(args, fmt.Sprintf("%s/%s.%%(ext)s", m.appConfig.Settings.DownloadFolder, item.outputName))
+			args = append(args, fmt.Sprintf("%s/%s.%%(ext)s", folder, item.outputName))
+		} else if tagFolder {
+			args = append(args, "-o", fmt.Sprintf("%s/%%(title)s [%%(id)s].%%(ext)s", folder))
 		}
+		metadataFile := filepath.Join(os.TempDir(), fmt.Sprintf("telecharger-%d.json", item.id))
+		_ = os.Remove(metadataFile)
+		defer os.Remove(metadataFile)
+		args = append(args, ytdlp.MetadataArgs(metadataFile)...)
+
 		args = append(args, item.videoId)
-		cmd := exec.Command("yt-dlp", args...) //nolint:gosec
-		stdout, _ := cmd.StdoutPipe()
-		stderr, _ := cmd.StderrPipe()
-		_ = cmd.Start()
+		cmd := exec.Command(ytdlp.Binary, args...) //nolint:gosec
+		logFile, err := utils.CreateDownloadLog(data.Path(), item.id)
+		if err != nil {
+			log.Print(err.Error())
+		} else {
+			defer logFile.Close()
+			fmt.Fprintf(logFile, "$ yt-dlp %s\n", strings.Join(args, " "))
+		}
+
+		// a download that won't fit goes back on the queue rather than failing
+		needed, problem := preflight(folder, minFree, item.videoId, probeArgs)
+		if len(problem) > 0 {
+			if logFile != nil {
+				fmt.Fprintln(logFile, problem)
+			}
+			data.UpdateQueueItemStatus(item.id, "queued")
+			return downloadFinished{noSpace: problem, needed: needed}
+		}
 
-		scanner := bufio.NewScanner(io.MultiReader(stdout, stderr))
-		scanner.Split(bufio.ScanWords)
+		output, outputWriter := io.Pipe()
+		cmd.Stdout = outputWriter
+		cmd.Stderr = outputWriter
+		if err := cmd.Start(); err != nil {
+			if logFile != nil {
+				fmt.Fprintln(logFile, err.Error())
+			}
+			data.UpdateQueueItemStatus(item.id, "error")
+			return downloadFinished{
+				finished: false,
+			}
+		}
+
+		waitErr := make(chan error, 1)
+		go func() {
+			err := cmd.Wait()
+			outputWriter.Close()
+			waitErr <- err
+		}()
+
+		noSpace := false
+		scanner := bufio.NewScanner(output)
+		scanner.Split(scanLogLines)
 		for scanner.Scan() {
-			t := scanner.Text()
-			if strings.Contains(t, "%") {
-				P.Send(downloadingStatusUpdate{content: t})
+			line := scanner.Text()
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			noSpace = noSpace || diskspace.IsNoSpace(line)
+			if logFile != nil {
+				fmt.Fprintln(logFile, line)
+			}
+			P.Send(downloadLogLine{id: item.id, content: line})
+			if percent := progressPattern.FindStringSubmatch(line); percent != nil {
+				P.Send(downloadingStatusUpdate{content: percent[1]})
+			}
+		}
+		// drain anything left if the scanner gave up on an overlong line
+		_, _ = io.Copy(io.Discard, output)
+
+		if err := <-waitErr; err != nil {
+			if logFile != nil {
+				fmt.Fprintln(logFile, err.Error())
+			}
+			if noSpace {
+				problem := fmt.Sprintf("ran out of disk space in %s", folder)
+				data.FailQueueItem(item.id, problem)
+				return downloadFinished{noSpace: problem, needed: needed}
+			}
+			data.UpdateQueueItemStatus(item.id, "error")
+			return downloadFinished{
+				finished: false,
 			}
 		}
 
+		if metadata, err := ytdlp.ReadMetadata(metadataFile); err == nil {
+			_ = data.UpdateQueueItemMetadata(item.id, data.Metadata{
+				Title:      metadata.Title,
+				Uploader:   metadata.Uploader,
+				Duration:   metadata.Duration,
+				Thumbnail:  metadata.Thumbnail,
+				FilePath:   metadata.FilePath,
+				FileSize:   metadata.FileSize,
+				Extractor:  metadata.Extractor,
+				WebpageUrl: metadata.WebpageURL,
+			})
+		}
+
 		data.UpdateQueueItemStatus(item.id, "completed")
 		notifyMe(item)
 		return downloadFinished{
@@ -171,12 +521,153 @@ func (m model) executeDownload(item QueueItem) tea.Cmd {
 	}
 }
 
+// startDownload marks item as downloading and kicks off yt-dlp for it.
+func (m *model) startDownload(item QueueItem) tea.Cmd {
+	data.UpdateQueueItemStatus(item.id, "downloading")
+	m.downloading = true
+	m.currentDownload = item
+	m.downloadLog = []string{}
+	m.viewport.SetContent("")
+	m.initLists(m.width, m.height)
+	return m.executeDownload(item)
+}
+
+// startNextDownload starts the item at the front of the queue, honouring the
+// queue's priority and position ordering, the download windows and each item's
+// earliest start time.
+func (m *model) startNextDownload() tea.Cmd {
+	if m.downloading {
+		return nil
+	}
+
+	now := time.Now()
+	if !m.schedule.Open(now) || !m.checkDiskSpace() {
+		return nil
+	}
+
+	next, err := data.GetNextQueueItem(now)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if next == nil {
+		return nil
+	}
+
+	return m.startDownload(newQueueItemFromData(next))
+}
+
+// applyConfig switches to a config saved from the settings screen without
+// restarting. Running downloads keep the options they were started with.
+func (m *model) applyConfig(cfg utils.Config) {
+	previous := m.appConfig.Settings
+	m.appConfig = cfg
+
+	if len(cfg.Settings.YtdlpPath) > 0 {
+		ytdlp.Binary = cfg.Settings.YtdlpPath
+	}
+	if cfg.Settings.AutoStart != previous.AutoStart {
+		m.autoStart = cfg.Settings.AutoStart
+	}
+	if cfg.Settings.EnableLogging != previous.EnableLogging {
+		setLogging(cfg.Settings.EnableLogging)
+	}
+
+	if cfg.Settings.DatabasePath != previous.DatabasePath {
+		m.reopenDatabase(cfg.Settings)
+	}
+
+	var err error
+	if m.schedule, err = schedule.Parse(cfg.Settings.DownloadWindows); err != nil {
+		m.err = err
+	}
+	if m.rateLimits, err = ratelimit.New(cfg.Settings); err != nil {
+		m.err = err
+	}
+	m.refreshLimits(time.Now())
+
+	if m.ready && data.RetentionFromSettings(cfg.Settings) != data.RetentionFromSettings(previous) {
+		m.applyRetention()
+		m.initLists(m.width, m.height)
+	}
+}
+
+// reopenDatabase switches to the database for settings, which needs the
+// running download to finish first as it records its progress in the old one.
+func (m *model) reopenDatabase(settings utils.SettingsConfig) {
+	if m.downloading {
+		m.profileStatus = "restart telecharger to use the new database"
+		return
+	}
+
+	// the current database stays open when the new one can't be opened
+	if err := data.Open(settings); err != nil {
+		m.profileStatus = err.Error()
+		return
+	}
+	m.initLists(m.width, m.height)
+}
+
+// setLogging starts or stops writing the debug log while the app is running.
+func setLogging(enabled bool) {
+	if !enabled {
+		log.SetOutput(io.Discard)
+		return
+	}
+	if _, err := tea.LogToFile("debug.log", "debug"); err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// openLogViewer shows the yt-dlp output for item, following it live when it is
+// the download in progress.
+func (m *model) openLogViewer(item QueueItem) {
+	m.logViewer = true
+	m.logViewerItem = item
+
+	if m.downloading && item.id == m.currentDownload.id {
+		m.logViewport.SetContent(strings.Join(m.downloadLog, "\n"))
+		m.logViewport.GotoBottom()
+		return
+	}
+
+	content, err := utils.ReadDownloadLog(data.Path(), item.id)
+	if err != nil {
+		content = "No log has been recorded for this item yet."
+	}
+	m.logViewport.SetContent(content)
+	m.logViewport.GotoTop()
+}
+
+// selectQueueItem moves the cursor of the queued list to the item with id.
+func (m *model) selectQueueItem(id int) {
+	for i, listItem := range m.lists[queued].Items() {
+		if listItem.(QueueItem).id == id {
+			m.lists[queued].Select(i)
+			return
+		}
+	}
+}
+
 func InitialModel(cfg utils.Config) *model {
-	return &model{
+	downloadWindows, err := schedule.Parse(cfg.Settings.DownloadWindows)
+	rateLimits, rateErr := ratelimit.New(cfg.Settings)
+	if err == nil {
+		err = rateErr
+	}
+	m := &model{
 		dialogChoice: 0,
 		progress:     progress.New(progress.WithDefaultGradient()),
 		appConfig:    cfg,
+		selected:     map[int]bool{},
+		autoStart:    cfg.Settings.AutoStart,
+		schedule:     downloadWindows,
+		rateLimits:   rateLimits,
+		diskFree:     -1,
+		err:          err,
 	}
+	m.refreshLimits(time.Now())
+	return m
 }
 
 func (m *model) Next() {
@@ -217,12 +708,7 @@ func (m *model) initLists(width, height int) {
 		fmt.Println(err.Error())
 	}
 
-	doneItems, err := data.GetAllQueueItems("completed")
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-
-	downloadingItems, err := data.GetAllQueueItems("downloading")
+	downloadingItems, err := data.GetAllQueueItems("downloading", "error")
 	if err != nil {
 		fmt.Println(err.Error())
 	}
@@ -239,30 +725,9 @@ func (m *model) initLists(width, height int) {
 
 	queueItemsList := []list.Item{}
 	for _, item := range queueItems {
-		queueItemsList = append(queueItemsList,
-			QueueItem{
-				id:             item.Id,
-				videoId:        item.VideoId,
-				outputName:     item.OutputName,
-				embedThumbnail: item.EmbedThumbnail,
-				audioOnly:      item.AudioOnly,
-				audioFormat:    item.AudioFormat,
-				extraCommands:  item.ExtraCommands,
-			})
-	}
-
-	doneItemsList := []list.Item{}
-	for _, item := range doneItems {
-		doneItemsList = append(doneItemsList,
-			QueueItem{
-				id:             item.Id,
-				videoId:        item.VideoId,
-				outputName:     item.OutputName,
-				embedThumbnail: item.EmbedThumbnail,
-				audioOnly:      item.AudioOnly,
-				audioFormat:    item.AudioFormat,
-				extraCommands:  item.ExtraCommands,
-			})
+		queueItem := newQueueItemFromData(item)
+		queueItem.selected = m.selected[item.Id]
+		queueItemsList = append(queueItemsList, queueItem)
 	}
 
 	downloadingItemsList := []list.Item{}
@@ -273,44 +738,60 @@ func (m *model) initLists(width, height int) {
 		} else if item.Status == "error" {
 			outputSymbol = "❌"
 		}
-		downloadingItemsList = append(downloadingItemsList,
-			QueueItem{
-				id:             item.Id,
-				videoId:        item.VideoId,
-				outputName:     fmt.Sprintf("%s %s", outputSymbol, item.OutputName),
-				embedThumbnail: item.EmbedThumbnail,
-				audioOnly:      item.AudioOnly,
-				audioFormat:    item.AudioFormat,
-				extraCommands:  item.ExtraCommands,
-			})
+		downloadingItem := newQueueItemFromData(item)
+		downloadingItem.selected = m.selected[item.Id]
+		downloadingItem.outputName = fmt.Sprintf("%s %s", outputSymbol, item.OutputName)
+		downloadingItemsList = append(downloadingItemsList, downloadingItem)
 	}
 
 	m.lists[queued].Styles.Title = ListTitle
 	m.lists[queued].Styles.ActivePaginationDot = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
-	m.lists[queued].Title = "Queued"
-	m.lists[queued].SetItems(queueItemsList)
+	m.lists[queued].Title = m.listTitle("Queued")
+	m.lists[queued].SetItems(m.filterTag(queueItemsList))
 
 	m.lists[done].Styles.Title = ListTitle
 	m.lists[done].Styles.ActivePaginationDot = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
-	m.lists[done].Title = "Done"
-	m.lists[done].SetItems(doneItemsList)
+	m.lists[done].SetItems(m.doneItems())
+	m.lists[done].Title = m.doneTitle()
 
 	m.lists[downloading].Styles.Title = ListTitle
 	m.lists[downloading].Styles.ActivePaginationDot = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
-	m.lists[downloading].Title = "Download status"
-	m.lists[downloading].SetItems(downloadingItemsList)
+	m.lists[downloading].Title = m.listTitle("Download status")
+	m.lists[downloading].SetItems(m.filterTag(downloadingItemsList))
 }
 
 type KeyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	Left     key.Binding
-	Right    key.Binding
-	Quit     key.Binding
-	Download key.Binding
-	Delete   key.Binding
-	Enter    key.Binding
-	Create   key.Binding
+	Up            key.Binding
+	Down          key.Binding
+	Left          key.Binding
+	Right         key.Binding
+	Quit          key.Binding
+	Download      key.Binding
+	Delete        key.Binding
+	Enter         key.Binding
+	Create        key.Binding
+	MoveUp        key.Binding
+	MoveDown      key.Binding
+	MoveTop       key.Binding
+	AutoStart     key.Binding
+	Select        key.Binding
+	SelectAll     key.Binding
+	Bulk          key.Binding
+	Back          key.Binding
+	Log           key.Binding
+	Subscriptions key.Binding
+	History       key.Binding
+	Stats         key.Binding
+	Tags          key.Binding
+	ClearHistory  key.Binding
+	Settings      key.Binding
+	Profiles      key.Binding
+	Import        key.Binding
+	QueueCopied   key.Binding
+	DismissCopied key.Binding
+	RateUp        key.Binding
+	RateDown      key.Binding
+	RateReset     key.Binding
 }
 
 var DefaultKeyMap = KeyMap{
@@ -350,10 +831,98 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "create new queued item"),
 	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("K", "shift+up"),
+		key.WithHelp("K/shift+↑", "move queued item up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("J", "shift+down"),
+		key.WithHelp("J/shift+↓", "move queued item down"),
+	),
+	MoveTop: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "move queued item to top"),
+	),
+	AutoStart: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "toggle auto start of the next queued item"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "select/deselect item"),
+	),
+	SelectAll: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "select/deselect all items matching the filter"),
+	),
+	Bulk: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "bulk actions on selected items"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+	Log: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "show the yt-dlp log of the selected item"),
+	),
+	Subscriptions: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "manage subscriptions"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "search the download history"),
+	),
+	Stats: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "show download statistics"),
+	),
+	Tags: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "filter the lists by tag"),
+	),
+	ClearHistory: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "clear the Done list"),
+	),
+	Settings: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "settings"),
+	),
+	Profiles: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "switch profile"),
+	),
+	Import: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "import a queue export or batch file"),
+	),
+	QueueCopied: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "queue the URL copied to the clipboard"),
+	),
+	DismissCopied: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "don't offer the copied URL again"),
+	),
+	RateUp: key.NewBinding(
+		key.WithKeys("+"),
+		key.WithHelp("+", "raise the rate limit from the next download"),
+	),
+	RateDown: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "lower the rate limit from the next download"),
+	),
+	RateReset: key.NewBinding(
+		key.WithKeys("="),
+		key.WithHelp("=", "go back to the configured rate limit from the next download"),
+	),
 }
 
 func (m *model) Init() tea.Cmd {
-	return m.spinner.Tick
+	return tea.Batch(m.spinner.Tick, m.checkSources(), sourceTick(), clipboardTick())
 }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -363,6 +932,35 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	)
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// let the list have the keys while a filter is being typed
+		if m.ready && m.lists[m.focused].SettingFilter() {
+			break
+		}
+		if m.profilePicker {
+			return m.updateProfilePicker(msg)
+		}
+		if m.tagPicker {
+			return m.updateTagPicker(msg)
+		}
+		if m.importPrompt {
+			return m.updateImportPrompt(msg)
+		}
+		if m.confirm != nil || m.bulkMenu || m.presetPicker {
+			return m.updateBulk(msg)
+		}
+		if m.logViewer {
+			if key.Matches(msg, DefaultKeyMap.Back, DefaultKeyMap.Quit, DefaultKeyMap.Log) {
+				m.logViewer = false
+				return m, nil
+			}
+			m.logViewport, cmd = m.logViewport.Update(msg)
+			return m, cmd
+		}
+		if len(m.clipboardOffer) > 0 && !m.blockExit {
+			if cmd, handled := m.updateClipboardOffer(msg); handled {
+				return m, cmd
+			}
+		}
 		switch {
 		case key.Matches(msg, DefaultKeyMap.Left):
 			if m.blockExit {
@@ -377,8 +975,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Next()
 			}
 		case key.Matches(msg, DefaultKeyMap.Quit):
-			fmt.Println(len(m.lists[downloading].Items()))
-			if len(m.lists[downloading].Items()) > 0 {
+			if m.downloading {
 				m.blockExit = true
 				return m, nil
 			} else {
@@ -389,15 +986,54 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// block multiple downloads
 			if m.downloading || len(m.lists[queued].Items()) == 0 {
 				return m, nil
-			} else {
+			} else if m.focused == queued {
 				selectedItem := m.lists[m.focused].SelectedItem()
 				item := selectedItem.(QueueItem)
-				data.UpdateQueueItemStatus(item.id, "downloading")
-				m.downloading = true
-				m.currentDownload = item
-				m.initLists(m.width, m.height)
-				return m, m.executeDownload(item)
+				return m, m.startDownload(item)
+			} else {
+				return m, m.startNextDownload()
+			}
+		case key.Matches(msg, DefaultKeyMap.AutoStart):
+			m.autoStart = !m.autoStart
+			if m.autoStart && !m.downloading {
+				return m, m.startNextDownload()
 			}
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.RateUp):
+			m.rateLimitOverride = ratelimit.Raise(m.globalRateLimit())
+			m.rateLimitAdjusted = true
+			m.refreshLimits(time.Now())
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.RateDown):
+			m.rateLimitOverride = ratelimit.Lower(m.globalRateLimit())
+			m.rateLimitAdjusted = true
+			m.refreshLimits(time.Now())
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.RateReset):
+			m.rateLimitAdjusted = false
+			m.refreshLimits(time.Now())
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.MoveUp, DefaultKeyMap.MoveDown, DefaultKeyMap.MoveTop):
+			if m.focused != queued || len(m.lists[queued].Items()) == 0 || m.lists[queued].FilterState() != list.Unfiltered || len(m.tagFilter) > 0 {
+				return m, nil
+			}
+			item := m.lists[queued].SelectedItem().(QueueItem)
+			var err error
+			switch {
+			case key.Matches(msg, DefaultKeyMap.MoveUp):
+				err = data.MoveQueueItem(item.id, -1)
+			case key.Matches(msg, DefaultKeyMap.MoveDown):
+				err = data.MoveQueueItem(item.id, 1)
+			default:
+				err = data.MoveQueueItemToTop(item.id)
+			}
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.initLists(m.width, m.height)
+			m.selectQueueItem(item.id)
+			return m, nil
 		case key.Matches(msg, DefaultKeyMap.Delete):
 			if m.focused != queued || len(m.lists[m.focused].Items()) == 0 {
 				return m, nil
@@ -407,9 +1043,54 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			data.DeleteQueueItem(item.id)
 			m.initLists(m.width, m.height)
 			return m, nil
+		case key.Matches(msg, DefaultKeyMap.Select):
+			m.toggleSelected()
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.SelectAll):
+			m.toggleSelectAll()
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.Bulk):
+			if len(m.selectedItems()) > 0 {
+				m.bulkMenu = true
+				m.bulkChoice = 0
+			}
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.Log):
+			if len(m.lists[m.focused].Items()) == 0 {
+				return m, nil
+			}
+			m.openLogViewer(m.lists[m.focused].SelectedItem().(QueueItem))
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.Subscriptions):
+			Models[Info] = m
+			Models[Subscriptions] = NewSubscriptions(m.appConfig, m.width, m.height)
+			return Models[Subscriptions], nil
+		case key.Matches(msg, DefaultKeyMap.History):
+			Models[Info] = m
+			Models[History] = NewHistory(m.appConfig, m.width, m.height)
+			return Models[History], textinput.Blink
+		case key.Matches(msg, DefaultKeyMap.Stats):
+			Models[Info] = m
+			Models[Stats] = NewStats(m.appConfig, m.width, m.height)
+			return Models[Stats], nil
+		case key.Matches(msg, DefaultKeyMap.Profiles):
+			m.openProfilePicker()
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.Tags):
+			m.openTagPicker()
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.ClearHistory):
+			m.confirmClearHistory()
+			return m, nil
+		case key.Matches(msg, DefaultKeyMap.Import):
+			return m, m.openImportPrompt()
+		case key.Matches(msg, DefaultKeyMap.Settings):
+			Models[Info] = m
+			Models[Settings] = NewSettings(m.appConfig, m.width, m.height)
+			return Models[Settings], textinput.Blink
 		case key.Matches(msg, DefaultKeyMap.Create):
 			Models[Info] = m
-			Models[Form] = NewForm()
+			Models[Form] = NewForm(m.appConfig)
 			return Models[Form].Update(nil)
 		case key.Matches(msg, DefaultKeyMap.Enter):
 			if len(m.lists[m.focused].Items()) == 0 && !m.blockExit {
@@ -447,23 +1128,27 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch m.focused {
 				case queued:
 					m.queueItemDetails = QueueItem{
-						id:             item.id,
-						videoId:        item.videoId,
-						outputName:     item.outputName,
-						embedThumbnail: item.embedThumbnail,
-						audioOnly:      item.audioOnly,
-						audioFormat:    item.audioFormat,
-						extraCommands:  item.extraCommands,
+						id:            item.id,
+						videoId:       item.videoId,
+						outputName:    item.outputName,
+						audioFormat:   item.audioFormat,
+						extraCommands: item.extraCommands,
+						priority:      item.priority,
+						preset:        item.preset,
+						notBefore:     item.notBefore,
+						rateLimit:     item.rateLimit,
+						options:       item.options,
+						tags:          item.tags,
 					}
 				case done:
 					m.doneItemDetails = QueueItem{
-						id:             item.id,
-						videoId:        item.videoId,
-						outputName:     item.outputName,
-						embedThumbnail: item.embedThumbnail,
-						audioOnly:      item.audioOnly,
-						audioFormat:    item.audioFormat,
-						extraCommands:  item.extraCommands,
+						id:            item.id,
+						videoId:       item.videoId,
+						outputName:    item.outputName,
+						audioFormat:   item.audioFormat,
+						extraCommands: item.extraCommands,
+						options:       item.options,
+						tags:          item.tags,
 					}
 				}
 			}
@@ -477,9 +1162,54 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.progress = progressModel.(progress.Model)
 		return m, cmd
 
-	case QueueItem:
+	case QueueItem, listsChanged:
 		m.initLists(m.width, m.height)
 
+	case queueItemsAdded:
+		m.transferStatus = fmt.Sprintf("added %d items", msg.added)
+		m.initLists(m.width, m.height)
+
+	case configChanged:
+		m.applyConfig(msg.config)
+
+	case sourceTickMsg:
+		// picks up items once the download window opens, their start time
+		// passes or there is disk space for them again
+		m.refreshLimits(time.Time(msg))
+		m.checkDiskSpace()
+		if len(m.batch) > 0 {
+			cmds = append(cmds, m.startNextInBatch())
+		} else if m.autoStart {
+			cmds = append(cmds, m.startNextDownload())
+		}
+		return m, tea.Batch(append(cmds, m.checkSources(), sourceTick())...)
+
+	case clipboardTickMsg:
+		if m.appConfig.Settings.WatchClipboard {
+			return m, tea.Batch(readClipboard, clipboardTick())
+		}
+		m.clipboardOffer = ""
+		return m, clipboardTick()
+
+	case clipboardRead:
+		m.checkClipboard(msg)
+		return m, nil
+
+	case sourcesChecked:
+		m.checkingSources = false
+		if msg.err != nil {
+			m.sourcesStatus = fmt.Sprintf("subscriptions and feeds: %v", msg.err)
+		} else {
+			m.sourcesStatus = fmt.Sprintf("subscriptions and feeds checked %s, %d new", time.Now().Format("15:04"), msg.added)
+		}
+		if msg.added > 0 {
+			m.initLists(m.width, m.height)
+			if m.autoStart {
+				return m, m.startNextDownload()
+			}
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		if !m.ready {
 			m.width, m.height = msg.Width, msg.Height
@@ -487,10 +1217,14 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			ContainerStyle.Width(msg.Width - 10)
 			FocusedStyle.Height(msg.Height / 5)
 			FocusedStyle.Width(msg.Width - 10)
+			m.applyRetention()
+			m.checkDiskSpace()
 			m.initLists(msg.Width, msg.Height)
-			m.viewport = viewport.New(msg.Width, msg.Height/7)
+			m.viewport = viewport.New(msg.Width-14, msg.Height/7)
 			m.viewport.HighPerformanceRendering = useHighPerformanceRenderer
-			m.viewport.SetContent(m.downloadOutput)
+			m.viewport.SetContent(strings.Join(m.downloadLog, "\n"))
+			m.logViewport = viewport.New(msg.Width-14, msg.Height-10)
+			m.logViewport.HighPerformanceRendering = useHighPerformanceRenderer
 			m.progress.Width = msg.Width - padding*2 - 4
 			if m.progress.Width > maxWidth {
 				m.progress.Width = maxWidth
@@ -498,22 +1232,49 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.ready = true
 
 		} else {
-			m.viewport.Width = msg.Width
+			m.viewport.Width = msg.Width - 14
 			m.viewport.Height = msg.Height / 7
+			m.logViewport.Width = msg.Width - 14
+			m.logViewport.Height = msg.Height - 10
 		}
 
 	case downloadingStatusUpdate:
-		m.downloadOutput = msg.content
-		percentString := removeLastRune(msg.content)
-		float, _ := strconv.ParseFloat(percentString, 64)
+		float, _ := strconv.ParseFloat(msg.content, 64)
 		cmd := m.progress.SetPercent(float / 100)
 		return m, cmd
 
+	case downloadLogLine:
+		m.downloadLog = append(m.downloadLog, msg.content)
+		if len(m.downloadLog) > maxLogLines {
+			m.downloadLog = m.downloadLog[len(m.downloadLog)-maxLogLines:]
+		}
+		m.viewport.SetContent(strings.Join(m.downloadLog, "\n"))
+		m.viewport.GotoBottom()
+		if m.logViewer && m.logViewerItem.id == msg.id {
+			m.logViewport.SetContent(strings.Join(m.downloadLog, "\n"))
+			m.logViewport.GotoBottom()
+		}
+		return m, nil
+
 	case downloadFinished:
 		m.downloading = false
 		cmd := m.progress.SetPercent(0)
 		m.currentDownload = QueueItem{}
+		m.applyRetention()
 		m.initLists(m.width, m.height)
+		if len(msg.noSpace) > 0 {
+			// nothing else starts until there is room for this download
+			m.diskNeeded = msg.needed
+			m.checkDiskSpace()
+			m.diskStatus = msg.noSpace
+			return m, cmd
+		}
+		if len(m.batch) > 0 {
+			return m, tea.Batch(cmd, m.startNextInBatch())
+		}
+		if m.autoStart {
+			return m, tea.Batch(cmd, m.startNextDownload())
+		}
 		return m, cmd
 	}
 
@@ -521,18 +1282,37 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		currList, cmd := m.lists[m.focused].Update(msg)
 		m.lists[m.focused] = currList
 		cmds = append(cmds, cmd)
+		if m.focused == done {
+			cmds = append(cmds, m.loadMoreDone())
+		}
 	}
 	m.spinner, cmd = m.spinner.Update(msg)
 	cmds = append(cmds, cmd)
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
+	if m.importPrompt {
+		m.importPath, cmd = m.importPath.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 	return m, tea.Batch(cmds...)
 }
 
 // VIEWS START
 func (m model) nameView() string {
 	oneWide := int(float64(m.width - 8))
-	version := fmt.Sprintf("  Version: %s\n  Author: James Best", version)
+	version := fmt.Sprintf("  Version: %s\n  Author: James Best\n  Profile: %s (p to switch)", version, utils.Profile())
+	if len(m.profileStatus) > 0 {
+		version += "\n  " + WarningStyle.Render(m.profileStatus)
+	}
+	if len(m.transferStatus) > 0 {
+		version += "\n  " + m.transferStatus
+	}
+	if len(m.historyStatus) > 0 {
+		version += "\n  " + m.historyStatus
+	}
+	if len(m.diskStatus) > 0 {
+		version += "\n  " + WarningStyle.Render(m.diskStatus)
+	}
 
 	acsi := `
   _       _           _
@@ -570,15 +1350,20 @@ func (m model) queueItemDetailsView() string {
 	videoId := fmt.Sprintf("Video Id: %s", m.queueItemDetails.videoId)
 	outputName := fmt.Sprintf("Outname: %s", m.queueItemDetails.outputName)
 	audioFormat := fmt.Sprintf("AudioFormat: %s", m.queueItemDetails.audioFormat)
-	audioOnly := fmt.Sprintf("AudioOnly: %s", strconv.FormatBool(m.queueItemDetails.audioOnly))
+	priority := fmt.Sprintf("Priority: %d", m.queueItemDetails.priority)
+	preset := fmt.Sprintf("Preset: %s", m.queueItemDetails.preset)
+	notBefore := "Not before: -"
+	if m.queueItemDetails.notBefore.Valid {
+		notBefore = fmt.Sprintf("Not before: %s", m.queueItemDetails.notBefore.Time.Local().Format("2006-01-02 15:04"))
+	}
+	rateLimit := "Rate limit: global"
+	if len(m.queueItemDetails.rateLimit) > 0 {
+		rateLimit = fmt.Sprintf("Rate limit: %s", m.queueItemDetails.rateLimit)
+	}
+	tags := fmt.Sprintf("Tags: %s", m.queueItemDetails.tagsView())
+	lines := append([]string{outputName, videoId, audioFormat, priority, preset, notBefore, rateLimit, tags}, m.queueItemDetails.options.Summary()...)
 	return DetailsViewStyle.Render(
-		lipgloss.JoinVertical(
-			lipgloss.Left,
-			outputName,
-			videoId,
-			audioFormat,
-			audioOnly,
-		),
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
 	)
 }
 
@@ -586,41 +1371,75 @@ func (m model) doneItemDetailsView() string {
 	videoId := fmt.Sprintf("Video Id: %s", m.doneItemDetails.videoId)
 	outputName := fmt.Sprintf("Outname: %s", m.doneItemDetails.outputName)
 	audioFormat := fmt.Sprintf("AudioFormat: %s", m.doneItemDetails.audioFormat)
-	audioOnly := fmt.Sprintf("AudioOnly: %s", strconv.FormatBool(m.doneItemDetails.audioOnly))
+	tags := fmt.Sprintf("Tags: %s", m.doneItemDetails.tagsView())
+	lines := append([]string{outputName, videoId, audioFormat, tags}, m.doneItemDetails.options.Summary()...)
 	return DetailsViewStyle.Render(
-		lipgloss.JoinVertical(
-			lipgloss.Left,
-			outputName,
-			videoId,
-			audioFormat,
-			audioOnly,
-		),
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
 	)
 }
 
+// refreshLimits works out the schedule status and global rate limit shown on
+// the dashboard for now. It runs on the minute tick and when either changes,
+// as finding when a closed schedule next opens is too slow for every render.
+func (m *model) refreshLimits(now time.Time) {
+	m.scheduleStatus = m.scheduleStatusAt(now)
+	m.rateLimitNow = m.globalRateLimit()
+}
+
+// scheduleStatusAt describes whether the download windows allow the queue to
+// start downloads at now.
+func (m model) scheduleStatusAt(now time.Time) string {
+	if len(m.schedule) == 0 {
+		return "Schedule: downloads can start at any time"
+	}
+
+	if m.schedule.Open(now) {
+		return ActiveStyle.Render("Schedule: ▶ inside a download window")
+	}
+
+	resumes := m.schedule.NextOpen(now)
+	if resumes.IsZero() {
+		return WarningStyle.Render("Schedule: ⏸ paused, no download window ever opens")
+	}
+	return WarningStyle.Render(fmt.Sprintf("Schedule: ⏸ paused by schedule, resumes %s", resumes.Format("Mon 02 Jan 15:04")))
+}
+
+// rateLimitView describes the global rate limit and where it comes from.
+func (m model) rateLimitView() string {
+	source := "configured"
+	if m.rateLimitAdjusted {
+		source = "set from the dashboard"
+	} else if len(m.rateLimits.Periods) > 0 {
+		source = "from the rate limit schedule"
+	}
+	return fmt.Sprintf("Rate limit: %s (%s)", rate.Format(m.rateLimitNow), source)
+}
+
 func (m model) downloadingItemDetailsView() string {
 	progress := fmt.Sprintf("Progress: %s", m.progress.View())
 	videoId := fmt.Sprintf("Video Id: %s", m.currentDownload.videoId)
 	outputName := fmt.Sprintf("Outname: %s", m.currentDownload.outputName)
 	audioFormat := fmt.Sprintf("AudioFormat: %s", m.currentDownload.audioFormat)
-	audioOnly := fmt.Sprintf("AudioOnly: %s", strconv.FormatBool(m.currentDownload.audioOnly))
+	tags := fmt.Sprintf("Tags: %s", m.currentDownload.tagsView())
+	lines := append([]string{m.scheduleStatus, m.rateLimitView(), m.diskView(), progress, outputName, videoId, audioFormat, tags}, m.currentDownload.options.Summary()...)
 	return DetailsViewStyle.Render(
-		lipgloss.JoinVertical(
-			lipgloss.Left,
-			progress,
-			outputName,
-			videoId,
-			audioFormat,
-			audioOnly,
-		),
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
 	)
 }
 
 func (m model) helpView() string {
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("\n ↑/↓: navigate • ←/→: swap lists • c: create entry • s: start download • d: delete entry • q/ctrl+c: quit\n 📀: downloading • ❌ error\n")
+	autoStart := "off"
+	if m.autoStart {
+		autoStart = "on"
+	}
+	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(fmt.Sprintf("\n ↑/↓: navigate • ←/→: swap lists • c: create entry • s: start download • d: delete entry • q/ctrl+c: quit\n K/J: move queued item up/down • T: move to top • a: auto start (%s) • +/-/=: raise/lower/reset rate limit from the next download (%s)\n space: select • A: select all/filtered • b: bulk actions (%d selected) • l: show log\n u: subscriptions • h: history • S: stats • t: filter by tag • X: clear done • o: settings • i: import\n 📀: downloading • ❌ error • %s\n", autoStart, rate.Format(m.rateLimitNow), len(m.selectedItems()), m.sourcesStatus))
+	if len(m.clipboardOffer) > 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, m.clipboardOfferView(), help)
+	}
+	return help
 }
 
-func (m model) dialogView() string {
+func (m model) dialogView(message string) string {
 	var (
 		okButton, cancelButton string
 	)
@@ -634,7 +1453,7 @@ func (m model) dialogView() string {
 
 	}
 
-	question := lipgloss.NewStyle().Width(50).Align(lipgloss.Center).Render("You are currently downloading items.\nAre you sure you want to exit?")
+	question := lipgloss.NewStyle().Width(50).Align(lipgloss.Center).Render(message)
 	buttons := lipgloss.JoinHorizontal(lipgloss.Top, okButton, cancelButton)
 	ui := lipgloss.JoinVertical(lipgloss.Center, question, buttons)
 
@@ -650,6 +1469,20 @@ func (m model) footerView() string {
 	return lipgloss.JoinHorizontal(lipgloss.Center, info)
 }
 
+func (m model) logView() string {
+	return DetailsViewStyle.Render(m.viewport.View())
+}
+
+func (m model) logViewerView() string {
+	info := DetailsViewStyle.Render(fmt.Sprintf("%3.f%%", m.logViewport.ScrollPercent()*100))
+	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(" ↑/↓/pgup/pgdown: scroll • esc/l: close")
+	return lipgloss.JoinVertical(lipgloss.Left,
+		TitleStyle.Render(fmt.Sprintf("Log: %s", m.logViewerItem.outputName)),
+		DetailsViewStyle.Render(m.logViewport.View()),
+		lipgloss.JoinHorizontal(lipgloss.Center, info, help),
+	)
+}
+
 func (m model) View() string {
 	twoWide := int(math.Floor(float64(m.width-10) / 2))
 	oneWide := int(float64(m.width - 8))
@@ -662,7 +1495,43 @@ func (m model) View() string {
 
 	if m.blockExit {
 		return ContainerStyleNoBorder.Width(oneWide).Render(
-			m.dialogView(),
+			m.dialogView("You are currently downloading items.\nAre you sure you want to exit?"),
+		)
+	}
+
+	if m.confirm != nil {
+		return ContainerStyleNoBorder.Width(oneWide).Render(
+			m.dialogView(m.confirm.question),
+		)
+	}
+
+	if m.logViewer {
+		return ContainerStyle.Width(oneWide).Render(
+			m.logViewerView(),
+		)
+	}
+
+	if m.profilePicker {
+		return ContainerStyleNoBorder.Width(oneWide).Render(
+			m.pickerView("Switch profile", m.profiles, m.profileChoice),
+		)
+	}
+
+	if m.tagPicker {
+		return ContainerStyleNoBorder.Width(oneWide).Render(
+			m.pickerView("Filter by tag", m.tags, m.tagChoice),
+		)
+	}
+
+	if m.bulkMenu || m.presetPicker {
+		return ContainerStyleNoBorder.Width(oneWide).Render(
+			m.bulkMenuView(),
+		)
+	}
+
+	if m.importPrompt {
+		return ContainerStyleNoBorder.Width(oneWide).Render(
+			m.importPromptView(),
 		)
 	}
 
@@ -692,6 +1561,8 @@ func (m model) View() string {
 					lipgloss.JoinVertical(lipgloss.Left,
 						TitleStyle.Render("Download status"),
 						m.downloadingItemDetailsView(),
+						TitleStyle.Render("Log"),
+						m.logView(),
 					),
 				),
 				HelpContainerStyle.Width(oneWide).Render(
@@ -722,6 +1593,8 @@ func (m model) View() string {
 					lipgloss.JoinVertical(lipgloss.Left,
 						TitleStyle.Render("Download status"),
 						m.downloadingItemDetailsView(),
+						TitleStyle.Render("Log"),
+						m.logView(),
 					),
 				),
 				HelpContainerStyle.Width(oneWide).Render(
@@ -752,6 +1625,8 @@ func (m model) View() string {
 					lipgloss.JoinVertical(lipgloss.Left,
 						TitleStyle.Render("Download status"),
 						m.downloadingItemDetailsView(),
+						TitleStyle.Render("Log"),
+						m.logView(),
 					),
 				),
 				HelpContainerStyle.Width(oneWide).Render(
@@ -765,9 +1640,4 @@ func (m model) View() string {
 	return "..."
 }
 
-func removeLastRune(s string) string {
-	r := []rune(s)
-	return string(r[:len(r)-1])
-}
-
 // VIEWS END
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/jim-at-jibba/telecharger/diskspace"
+	"github.com/jim-at-jibba/telecharger/stats"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// minFreeSpace is the space downloads leave free on the download folder's
+// disk. The config check reports a value that doesn't parse, which counts as
+// no minimum here.
+func minFreeSpace(settings utils.SettingsConfig) int64 {
+	size, _ := diskspace.ParseSize(settings.MinFreeSpace)
+	return size
+}
+
+// checkDiskSpace looks up the space left on the download folder's disk and
+// reports whether downloads may start. There has to be more than the minimum,
+// and more than a download that didn't fit asked for. Disks that can't be
+// checked never stop downloads.
+func (m *model) checkDiskSpace() bool {
+	folder := m.appConfig.Settings.DownloadFolder
+	free, err := diskspace.Free(utils.ExpandHome(folder))
+	if err != nil {
+		m.diskFree = -1
+		return true
+	}
+	m.diskFree = free
+
+	needed := minFreeSpace(m.appConfig.Settings)
+	if m.diskNeeded > needed {
+		needed = m.diskNeeded
+	}
+	if free < needed {
+		m.diskStatus = fmt.Sprintf("downloads paused: %s free in %s, waiting for %s", stats.FormatBytes(free), folder, stats.FormatBytes(needed))
+		return false
+	}
+	m.diskNeeded = 0
+	m.diskStatus = ""
+	return true
+}
+
+// preflight estimates the size of a download with yt-dlp and returns why it
+// won't fit in folder with the minimum left free, along with the space it
+// needs. Downloads whose size or disk can't be checked go ahead.
+func preflight(folder string, minFree int64, url string, args []string) (int64, string) {
+	free, err := diskspace.Free(utils.ExpandHome(folder))
+	if err != nil {
+		return 0, ""
+	}
+	size, err := ytdlp.EstimateSize(url, args)
+	if err != nil {
+		size = 0
+	}
+	if free-size >= minFree {
+		return size + minFree, ""
+	}
+	if size == 0 {
+		return minFree, fmt.Sprintf("not enough disk space: %s free in %s, below the minimum of %s", stats.FormatBytes(free), folder, stats.FormatBytes(minFree))
+	}
+	return size + minFree, fmt.Sprintf("not enough disk space: the download needs about %s and %s is free in %s, keeping %s free", stats.FormatBytes(size), stats.FormatBytes(free), folder, stats.FormatBytes(minFree))
+}
+
+// diskView describes the space left for downloads.
+func (m model) diskView() string {
+	if len(m.diskStatus) > 0 {
+		return WarningStyle.Render("Disk: ⏸ " + m.diskStatus)
+	}
+	if m.diskFree < 0 {
+		return "Disk: free space unknown"
+	}
+	return fmt.Sprintf("Disk: %s free in %s", stats.FormatBytes(m.diskFree), m.appConfig.Settings.DownloadFolder)
+}
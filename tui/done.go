@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jim-at-jibba/telecharger/data"
+)
+
+// donePageSize is how many completed items the Done list loads at a time.
+const donePageSize = 20
+
+// doneItems loads the completed items the Done list showed before it was
+// rebuilt, and at least the first page of them.
+func (m *model) doneItems() []list.Item {
+	limit := m.doneLoaded
+	if limit < donePageSize {
+		limit = donePageSize
+	}
+
+	items, err := data.GetCompletedQueueItems(m.tagFilter, limit, 0)
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+	if m.doneTotal, err = data.CountCompletedQueueItems(m.tagFilter); err != nil {
+		fmt.Println(err.Error())
+	}
+
+	doneItems := []list.Item{}
+	for _, item := range items {
+		doneItem := newQueueItemFromData(item)
+		doneItem.selected = m.selected[item.Id]
+		doneItems = append(doneItems, doneItem)
+	}
+	return doneItems
+}
+
+// doneTitle is the title of the Done list, with how much of it is loaded
+// while some of it isn't.
+func (m *model) doneTitle() string {
+	title := "Done"
+	if loaded := len(m.lists[done].Items()); loaded < m.doneTotal {
+		title = fmt.Sprintf("Done (%d of %d)", loaded, m.doneTotal)
+	}
+	return m.listTitle(title)
+}
+
+// loadMoreDone loads the next page of the Done list once the cursor reaches
+// its last loaded item.
+func (m *model) loadMoreDone() tea.Cmd {
+	items := m.lists[done].Items()
+	if len(items) >= m.doneTotal || m.lists[done].FilterState() != list.Unfiltered || m.lists[done].Index() < len(items)-1 {
+		return nil
+	}
+
+	more, err := data.GetCompletedQueueItems(m.tagFilter, donePageSize, len(items))
+	if err != nil {
+		m.historyStatus = fmt.Sprintf("can't load more of the Done list: %v", err)
+		return nil
+	}
+	for _, item := range more {
+		doneItem := newQueueItemFromData(item)
+		doneItem.selected = m.selected[item.Id]
+		items = append(items, doneItem)
+	}
+	m.doneLoaded = len(items)
+	cmd := m.lists[done].SetItems(items)
+	m.lists[done].Title = m.doneTitle()
+	return cmd
+}
+
+// applyRetention archives or deletes the completed items the retention
+// settings don't keep.
+func (m *model) applyRetention() {
+	retention := data.RetentionFromSettings(m.appConfig.Settings)
+	removed, err := data.ApplyRetention(retention, time.Now())
+	if err != nil {
+		m.historyStatus = fmt.Sprintf("history retention: %v", err)
+		return
+	}
+	if removed > 0 {
+		m.historyStatus = fmt.Sprintf("%s %d old items from the Done list", retiredVerb(retention.Archive), removed)
+	}
+}
+
+// confirmClearHistory asks before archiving or deleting every item on the
+// Done list.
+func (m *model) confirmClearHistory() {
+	count, err := data.CountCompletedQueueItems("")
+	if err != nil {
+		m.historyStatus = fmt.Sprintf("clear history: %v", err)
+		return
+	}
+	if count == 0 {
+		m.historyStatus = "the Done list is already empty"
+		return
+	}
+
+	archive := m.appConfig.Settings.HistoryArchive
+	question := fmt.Sprintf("Delete all %d items on the Done list?", count)
+	if archive {
+		question = fmt.Sprintf("Archive all %d items on the Done list? They stay in the history.", count)
+	}
+	m.confirm = &confirmation{
+		question: question,
+		action: func() tea.Cmd {
+			removed, err := data.ClearHistory(archive, time.Now())
+			if err != nil {
+				m.historyStatus = fmt.Sprintf("clear history: %v", err)
+				return nil
+			}
+			m.doneLoaded = 0
+			m.historyStatus = fmt.Sprintf("%s %d items from the Done list", retiredVerb(archive), removed)
+			return nil
+		},
+	}
+}
+
+// retiredVerb describes what happened to items taken off the Done list.
+func retiredVerb(archive bool) string {
+	if archive {
+		return "archived"
+	}
+	return "deleted"
+}
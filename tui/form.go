@@ -1,23 +1,33 @@
 package tui
 
 import (
+	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/rate"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
 )
 
 /* FORM MODEL */
 type FormKeyMap struct {
-	Quit  key.Binding
-	Enter key.Binding
-	Back  key.Binding
-	Up    key.Binding
-	Down  key.Binding
-	Tab   key.Binding
+	Quit     key.Binding
+	Enter    key.Binding
+	Back     key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Tab      key.Binding
+	Bulk     key.Binding
+	Complete key.Binding
 }
 
 var DefaultFormKeyMap = FormKeyMap{
@@ -45,108 +55,472 @@ var DefaultFormKeyMap = FormKeyMap{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "select option"),
 	),
+	Bulk: key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "switch between one URL and a list of URLs"),
+	),
+	Complete: key.NewBinding(
+		key.WithKeys("right"),
+		key.WithHelp("→", "complete tag"),
+	),
 }
 
-// boolChoices
-// embedThumbnail
-// audioOnly
-
-type option int
+// formField identifies the text fields of the form, in tab order.
+type formField int
 
 const (
-	embedThumbnail option = iota
-	audioOnly
+	fieldVideoId formField = iota
+	fieldOutputName
+	fieldAudioFormat
+	fieldExtraCommands
+	fieldPriority
+	fieldPreset
+	fieldNotBefore
+	fieldRateLimit
+	fieldTags
+	// fieldOptions is the first field of the registered options that aren't
+	// checkboxes, which follow in registry order.
+	fieldOptions
 )
 
 type FormModel struct {
-	videoId         textinput.Model
-	outputName      textinput.Model
-	audioFormat     textinput.Model
-	extraCommands   textinput.Model
-	choosingOptions bool
-	choice          option
-	boolChoices     []option
+	videoId          textinput.Model
+	outputName       textinput.Model
+	audioFormat      textinput.Model
+	extraCommands    textinput.Model
+	notBefore        textinput.Model
+	rateLimit        textinput.Model
+	priority         textinput.Model
+	preset           textinput.Model
+	tags             textinput.Model
+	knownTags        []string
+	fieldOptions     []ytdlp.Option
+	optionInputs     []textinput.Model
+	checkOptions     []ytdlp.Option
+	checked          ytdlp.Options
+	choosingOptions  bool
+	choice           int
+	appConfig        utils.Config
+	duplicateWarning string
+	errorMessage     string
+	fieldErrors      []string
+	bulk             bool
+	urls             textarea.Model
+	bulkLines        []bulkLine
+	knownVideos      map[string]string
 }
 
-func (m FormModel) CreateQueuedItem() tea.Msg {
-	s := m.boolChoices
-	containsEmbed, _ := contains(s, 0)
-	containsAudioOnly, _ := contains(s, 1)
-	task := NewQueuedItem(
-		m.videoId.Value(),
-		m.outputName.Value(),
-		m.audioFormat.Value(),
-		m.extraCommands.Value(),
-		containsEmbed,
-		containsAudioOnly,
-	)
+// CreateQueuedItem adds the download described by the form to the queue.
+func (m FormModel) CreateQueuedItem() (QueueItem, error) {
+	videoId, err := utils.CanonicalURL(m.videoId.Value())
+	if err != nil {
+		return QueueItem{}, err
+	}
 
-	_ = data.InsertQueueItem(
-		m.videoId.Value(),
-		m.outputName.Value(),
-		m.audioFormat.Value(),
-		m.extraCommands.Value(),
-		containsEmbed,
-		containsAudioOnly,
-	)
-	return task
+	item := m.queueItem(videoId, strings.TrimSpace(m.outputName.Value()))
+	if err := data.InsertQueueItem(item); err != nil {
+		return QueueItem{}, err
+	}
+	return newQueueItemFromData(&item), nil
 }
 
-func NewForm() *FormModel {
-	form := &FormModel{}
+// queueItem builds the item for videoId from the form's options.
+func (m FormModel) queueItem(videoId, outputName string) data.QueueItem {
+	options := m.options()
+	priority := m.priorityValue()
+	audioFormat := strings.TrimSpace(m.audioFormat.Value())
+	extraCommands := m.extraCommands.Value()
+
+	// the preset provides defaults that the form's own values add to
+	presetName := strings.TrimSpace(m.preset.Value())
+	preset, ok := m.appConfig.Preset(presetName)
+	if ok {
+		options.SetBool(ytdlp.EmbedThumbnail, options.Bool(ytdlp.EmbedThumbnail) || preset.EmbedThumbnail)
+		options.SetBool(ytdlp.AudioOnly, options.Bool(ytdlp.AudioOnly) || preset.AudioOnly)
+		if len(audioFormat) == 0 {
+			audioFormat = preset.AudioFormat
+		}
+		extraCommands = strings.TrimSpace(preset.ExtraCommands + " " + extraCommands)
+	} else {
+		presetName = ""
+	}
+
+	// the form won't submit with an invalid time so the error can be ignored here
+	notBefore, _ := parseNotBefore(m.notBefore.Value(), time.Now())
+	tags, _ := data.ParseTags(m.tags.Value())
+
+	return data.QueueItem{
+		VideoId:       videoId,
+		OutputName:    outputName,
+		AudioFormat:   audioFormat,
+		ExtraCommands: extraCommands,
+		Preset:        presetName,
+		Priority:      priority,
+		NotBefore:     notBefore,
+		RateLimit:     strings.TrimSpace(m.rateLimit.Value()),
+		Options:       options,
+		Tags:          tags,
+	}
+}
+
+// options reads the registered options from their checkboxes and fields.
+func (m FormModel) options() ytdlp.Options {
+	options := m.checked.Clone()
+	for i, option := range m.fieldOptions {
+		options.Set(option.Name, m.optionInputs[i].Value())
+	}
+	return options
+}
+
+// notBeforeLayouts are the formats accepted for the not before field.
+var notBeforeLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// parseNotBefore reads the time an item may start downloading. A bare time of
+// day means its next occurrence and an empty value means no restriction.
+func parseNotBefore(value string, now time.Time) (sql.NullTime, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return sql.NullTime{}, nil
+	}
+
+	for _, layout := range notBeforeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return sql.NullTime{Time: t, Valid: true}, nil
+		}
+	}
+
+	if clock, err := time.ParseInLocation("15:04", value, time.Local); err == nil {
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if t.Before(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return sql.NullTime{Time: t, Valid: true}, nil
+	}
+
+	return sql.NullTime{}, fmt.Errorf("invalid start time %q, use YYYY-MM-DD HH:MM, YYYY-MM-DD or HH:MM", value)
+}
+
+// priorityValue parses the priority field, treating anything that isn't a
+// whole number as the default priority of 0.
+func (m FormModel) priorityValue() int {
+	priority, err := strconv.Atoi(strings.TrimSpace(m.priority.Value()))
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+func NewForm(cfg utils.Config) *FormModel {
+	form := &FormModel{appConfig: cfg}
 	form.choosingOptions = false
 	form.videoId = textinput.New()
-	form.videoId.Placeholder = "Youtube video url"
+	form.videoId.Placeholder = "Video URL or YouTube video ID"
 	form.videoId.Focus()
 	form.outputName = textinput.New()
 	form.outputName.Placeholder = "New name"
 	form.audioFormat = textinput.New()
-	form.audioFormat.Placeholder = "Audio Format (mp3, m4a)"
+	form.audioFormat.Placeholder = fmt.Sprintf("Audio Format (%s)", strings.Join(ytdlp.AudioFormats, ", "))
 	form.extraCommands = textinput.New()
 	form.extraCommands.Placeholder = "Add extra commands not currently cupported by telegrapher"
+	form.priority = textinput.New()
+	form.priority.Placeholder = "Priority (number, higher downloads first, default 0)"
+	form.preset = textinput.New()
+	presetNames := []string{}
+	for _, preset := range cfg.Presets {
+		presetNames = append(presetNames, preset.Name)
+	}
+	form.preset.Placeholder = fmt.Sprintf("Preset (%s)", strings.Join(presetNames, ", "))
+	form.notBefore = textinput.New()
+	form.notBefore.Placeholder = "Don't start before (YYYY-MM-DD HH:MM or HH:MM)"
+	form.rateLimit = textinput.New()
+	form.rateLimit.Placeholder = "Rate limit (500K, 2M, 0 for none, default shares the global limit)"
+	form.tags = textinput.New()
+	form.tags.Placeholder = "Tags (comma separated)"
+	// without the existing tags there's nothing to complete, the field still works
+	form.knownTags, _ = data.GetAllTags()
+	form.checked = ytdlp.Options{}
+	for _, option := range ytdlp.Registered() {
+		if option.Type == ytdlp.BoolOption {
+			form.checkOptions = append(form.checkOptions, option)
+			continue
+		}
+		input := textinput.New()
+		input.Placeholder = optionPlaceholder(option)
+		form.fieldOptions = append(form.fieldOptions, option)
+		form.optionInputs = append(form.optionInputs, input)
+	}
+	form.fieldErrors = make([]string, form.fieldCount())
+	form.urls = newBulkTextarea()
 	return form
 }
 
-func (m FormModel) Init() tea.Cmd {
+// tagSuggestion returns an existing tag that starts with the tag being typed
+// at the end of the tags field, or an empty string.
+func (m FormModel) tagSuggestion() string {
+	value := m.tags.Value()
+	if m.tags.Cursor() < len(value) {
+		return ""
+	}
+	typed := value
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		typed = value[i+1:]
+	}
+	typed = strings.TrimPrefix(strings.TrimSpace(typed), "#")
+	if len(typed) == 0 {
+		return ""
+	}
+
+	used := map[string]bool{}
+	for _, tag := range strings.Split(value, ",") {
+		used[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))] = true
+	}
+	for _, tag := range m.knownTags {
+		if len(tag) > len(typed) && strings.HasPrefix(strings.ToLower(tag), strings.ToLower(typed)) && !used[strings.ToLower(tag)] {
+			return tag
+		}
+	}
+	return ""
+}
+
+// optionPlaceholder describes the value a registered option takes.
+func optionPlaceholder(option ytdlp.Option) string {
+	placeholder := option.Help
+	if option.Type == ytdlp.EnumOption {
+		placeholder = fmt.Sprintf("%s (%s)", placeholder, strings.Join(option.Choices, ", "))
+	}
+	if len(option.Default) > 0 {
+		placeholder = fmt.Sprintf("%s, default %s", placeholder, option.Default)
+	}
+	return placeholder
+}
+
+// fieldCount is the number of text fields, including those of the options.
+func (m FormModel) fieldCount() formField {
+	return fieldOptions + formField(len(m.optionInputs))
+}
+
+// input returns the text input of field.
+func (m *FormModel) input(field formField) *textinput.Model {
+	switch field {
+	case fieldVideoId:
+		return &m.videoId
+	case fieldOutputName:
+		return &m.outputName
+	case fieldAudioFormat:
+		return &m.audioFormat
+	case fieldExtraCommands:
+		return &m.extraCommands
+	case fieldPriority:
+		return &m.priority
+	case fieldPreset:
+		return &m.preset
+	case fieldNotBefore:
+		return &m.notBefore
+	case fieldRateLimit:
+		return &m.rateLimit
+	case fieldTags:
+		return &m.tags
+	default:
+		return &m.optionInputs[field-fieldOptions]
+	}
+}
+
+// focusedField returns the text field with the cursor, if any.
+func (m *FormModel) focusedField() (formField, bool) {
+	for field := fieldVideoId; field < m.fieldCount(); field++ {
+		if m.input(field).Focused() {
+			return field, true
+		}
+	}
+	return 0, false
+}
+
+// skipped reports whether field is unused, the URL and output name are
+// replaced by the list of URLs in bulk mode.
+func (m FormModel) skipped(field formField) bool {
+	return m.bulk && (field == fieldVideoId || field == fieldOutputName)
+}
+
+// checkField returns the problem with the value of field, or an empty string.
+func (m FormModel) checkField(field formField) string {
+	value := strings.TrimSpace(m.input(field).Value())
+	var err error
+	switch field {
+	case fieldVideoId:
+		_, err = utils.CanonicalURL(value)
+	case fieldOutputName:
+		if len(value) > 0 {
+			err = utils.ValidateFileName(value)
+		}
+	case fieldAudioFormat:
+		err = ytdlp.ValidateAudioFormat(value)
+	case fieldExtraCommands:
+		// passed to yt-dlp as they are
+	case fieldPriority:
+		if _, convErr := strconv.Atoi(value); len(value) > 0 && convErr != nil {
+			err = fmt.Errorf("priority must be a whole number")
+		}
+	case fieldPreset:
+		if _, ok := m.appConfig.Preset(value); len(value) > 0 && !ok {
+			err = fmt.Errorf("unknown preset %q", value)
+		}
+	case fieldNotBefore:
+		_, err = parseNotBefore(value, time.Now())
+	case fieldRateLimit:
+		_, err = rate.Parse(value)
+	case fieldTags:
+		_, err = data.ParseTags(value)
+	default:
+		err = m.fieldOptions[field-fieldOptions].Validate(value)
+	}
+
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// validate records the problem with field, if any, and reports whether it is
+// valid. A valid URL is replaced with its canonical form.
+func (m *FormModel) validate(field formField) bool {
+	m.fieldErrors[field] = m.checkField(field)
+	if len(m.fieldErrors[field]) > 0 {
+		return false
+	}
+	if field == fieldVideoId {
+		canonical, _ := utils.CanonicalURL(m.videoId.Value())
+		m.videoId.SetValue(canonical)
+	}
+	return true
+}
+
+// focusNext moves the cursor from field to the next one in use, or to the
+// options after the last field.
+func (m *FormModel) focusNext(field formField) tea.Cmd {
+	m.input(field).Blur()
+	for next := field + 1; next < m.fieldCount(); next++ {
+		if !m.skipped(next) {
+			return m.input(next).Focus()
+		}
+	}
+	m.choosingOptions = true
+	m.choice = 0
 	return nil
 }
 
-func contains(s []option, find int) (bool, int) {
-	for i, v := range s {
-		if int(v) == find {
-			return true, i
+// submit checks every field and adds the download, or the list of downloads
+// in bulk mode. Nothing is added while a field has a problem, the cursor goes
+// to the first one instead.
+func (m FormModel) submit() (tea.Model, tea.Cmd) {
+	invalid := m.fieldCount()
+	for field := fieldVideoId; field < m.fieldCount(); field++ {
+		if !m.skipped(field) && !m.validate(field) && invalid == m.fieldCount() {
+			invalid = field
+		}
+	}
+	if invalid < m.fieldCount() {
+		m.choosingOptions = false
+		return m, m.input(invalid).Focus()
+	}
+
+	if m.bulk {
+		if len(m.bulkURLs()) == 0 {
+			m.errorMessage = "There are no URLs to add."
+			return m, nil
+		}
+		added, err := m.CreateQueuedItems()
+		if err != nil {
+			m.errorMessage = fmt.Sprintf("Nothing was added: %v", err)
+			return m, nil
+		}
+		Models[Form] = m
+		return Models[Info], func() tea.Msg { return queueItemsAdded{added: added} }
+	}
+
+	// warn once about videos that were added before, a second tab adds it anyway
+	if len(m.duplicateWarning) == 0 {
+		if warning := m.duplicateCheck(); len(warning) > 0 {
+			m.duplicateWarning = warning
+			return m, nil
 		}
 	}
 
-	return false, 0
+	item, err := m.CreateQueuedItem()
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("The download couldn't be added: %v", err)
+		return m, nil
+	}
+	Models[Form] = m
+	return Models[Info], func() tea.Msg { return item }
+}
+
+// duplicateCheck describes the existing entries for the same video, or returns
+// an empty string when the video hasn't been added before.
+func (m FormModel) duplicateCheck() string {
+	if len(strings.TrimSpace(m.videoId.Value())) == 0 {
+		return ""
+	}
+
+	duplicates, err := data.FindDuplicateQueueItems(m.videoId.Value())
+	if err != nil || len(duplicates) == 0 {
+		return ""
+	}
+
+	statuses := []string{}
+	for _, duplicate := range duplicates {
+		statuses = append(statuses, fmt.Sprintf("%q is %s", duplicate.OutputName, duplicate.Status))
+	}
+
+	return fmt.Sprintf("This video has been added before (%s).\nPress tab again to add it anyway or esc to cancel.", strings.Join(statuses, ", "))
+}
+
+func (m FormModel) Init() tea.Cmd {
+	return nil
 }
 
 func (m FormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	dashboardCmd := forwardToDashboard(msg)
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, dashboardCmd)
+}
+
+func (m FormModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if key.Matches(msg, DefaultFormKeyMap.Bulk) && (m.videoId.Focused() || m.urls.Focused()) {
+			return m.toggleBulk()
+		}
+		// the list of URLs needs enter and the arrow keys for editing
+		if m.urls.Focused() && !key.Matches(msg, DefaultFormKeyMap.Tab, DefaultFormKeyMap.Back, DefaultFormKeyMap.Quit) {
+			return m, m.updateBulkText(msg)
+		}
+
 		switch {
 
+		case key.Matches(msg, DefaultFormKeyMap.Complete) && m.tags.Focused() && len(m.tagSuggestion()) > 0:
+			value := m.tags.Value()
+			prefix := value[:strings.LastIndex(value, ",")+1]
+			if len(prefix) > 0 {
+				prefix += " "
+			}
+			m.tags.SetValue(prefix + m.tagSuggestion())
+			m.tags.CursorEnd()
+			m.fieldErrors[fieldTags] = ""
+			return m, nil
 		case key.Matches(msg, DefaultFormKeyMap.Tab):
-			if m.videoId.Focused() {
-				m.videoId.Blur()
-				m.outputName.Focus()
-				return m, textinput.Blink
-			} else if m.outputName.Focused() {
-				m.outputName.Blur()
+			if m.urls.Focused() {
+				m.urls.Blur()
 				m.audioFormat.Focus()
 				return m, textinput.Blink
-			} else if m.audioFormat.Focused() {
-				m.audioFormat.Blur()
-				m.extraCommands.Focus()
-			} else if m.extraCommands.Focused() {
-				m.extraCommands.Blur()
-				m.choosingOptions = true
-				m.choice = embedThumbnail
-			} else {
-				Models[Form] = m
-				return Models[Info], m.CreateQueuedItem
 			}
+			if field, ok := m.focusedField(); ok {
+				if !m.validate(field) {
+					return m, nil
+				}
+				return m, m.focusNext(field)
+			}
+			return m.submit()
 		case key.Matches(msg, DefaultFormKeyMap.Quit):
 			Models[Form] = m
 			return Models[Info], nil
@@ -155,8 +529,8 @@ func (m FormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.choice++
-			if m.choice > 1 {
-				m.choice = 1
+			if m.choice >= len(m.checkOptions) {
+				m.choice = len(m.checkOptions) - 1
 			}
 		case key.Matches(msg, DefaultFormKeyMap.Up):
 			if !m.choosingOptions {
@@ -167,29 +541,31 @@ func (m FormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.choice = 0
 			}
 		case key.Matches(msg, DefaultFormKeyMap.Enter):
-			match, i := contains(m.boolChoices, int(m.choice))
-
-			if match {
-				m.boolChoices = append(m.boolChoices[:i], m.boolChoices[i+1:]...)
-			} else {
-				m.boolChoices = append(m.boolChoices, m.choice)
+			if !m.choosingOptions {
+				return m, nil
 			}
+			name := m.checkOptions[m.choice].Name
+			m.checked = m.checked.Clone()
+			m.checked.SetBool(name, !m.checked.Bool(name))
 		case key.Matches(msg, DefaultFormKeyMap.Back):
 			Models[Form] = m
 			return Models[Info], nil
 		}
 	}
-	if m.videoId.Focused() {
-		m.videoId, cmd = m.videoId.Update(msg)
+	if m.urls.Focused() {
+		m.urls, cmd = m.urls.Update(msg)
 		return m, cmd
-	} else if m.outputName.Focused() {
-		m.outputName, cmd = m.outputName.Update(msg)
-		return m, cmd
-	} else if m.audioFormat.Focused() {
-		m.audioFormat, cmd = m.audioFormat.Update(msg)
-		return m, cmd
-	} else if m.extraCommands.Focused() {
-		m.extraCommands, cmd = m.extraCommands.Update(msg)
+	}
+	if field, ok := m.focusedField(); ok {
+		input := m.input(field)
+		before := input.Value()
+		*input, cmd = input.Update(msg)
+		if input.Value() != before {
+			m.fieldErrors[field] = ""
+			if field == fieldVideoId {
+				m.duplicateWarning = ""
+			}
+		}
 		return m, cmd
 	}
 
@@ -206,40 +582,75 @@ func checkbox(label string, checked, selected bool, optionsActive bool) string {
 }
 
 func (m FormModel) choicesView() string {
-	c := m.choice
-	s := m.boolChoices
-
-	containsEmbed, _ := contains(s, 0)
-	containsAudioOnly, _ := contains(s, 1)
-
-	choices := fmt.Sprintf(
-		"%s\n%s\n",
-		checkbox("Embed Thumbnail", c == 0, containsEmbed, m.choosingOptions),
-		checkbox("Audio Only", c == 1, containsAudioOnly, m.choosingOptions),
-	)
+	choices := ""
+	for i, option := range m.checkOptions {
+		choices += checkbox(option.Label, m.choice == i, m.checked.Bool(option.Name), m.choosingOptions) + "\n"
+	}
+	if m.choosingOptions {
+		choices += InactiveStyle.Render(m.checkOptions[m.choice].Help) + "\n"
+	}
 
 	return choices
 }
 
 func (m FormModel) formHelpView() string {
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("\n ↑/↓: navigate options • enter: select/deselect option • tab: move to next/complete • ctrl+c: quit\n")
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("\n ↑/↓: navigate options • enter: select/deselect option • tab: move to next/complete • →: complete tag • ctrl+l: one URL/list of URLs • ctrl+c: quit\n")
+}
+
+// fieldView shows the input of field with its problem, if any, underneath.
+func (m FormModel) fieldView(field formField) string {
+	view := m.input(field).View()
+	if field == fieldTags && m.tags.Focused() {
+		if suggestion := m.tagSuggestion(); len(suggestion) > 0 {
+			view = lipgloss.JoinVertical(lipgloss.Left, view, InactiveStyle.Render("  → "+suggestion))
+		}
+	}
+	if problem := m.fieldErrors[field]; len(problem) > 0 {
+		view = lipgloss.JoinVertical(lipgloss.Left, view, WarningStyle.Render("  "+problem))
+	}
+	return view
+}
+
+// optionFieldsView shows the fields of the registered options that aren't
+// checkboxes.
+func (m FormModel) optionFieldsView() string {
+	fields := []string{}
+	for field := fieldOptions; field < m.fieldCount(); field++ {
+		fields = append(fields, m.fieldView(field))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, fields...)
 }
 
 func (m FormModel) View() string {
+	title := "Create new download"
+	source := lipgloss.JoinVertical(lipgloss.Left, m.fieldView(fieldVideoId), m.fieldView(fieldOutputName))
+	summary := ""
+	if m.bulk {
+		title = "Create several downloads"
+		source = m.urls.View()
+		summary = m.bulkSummaryView()
+	}
+
 	return lipgloss.JoinVertical(lipgloss.Left,
 
 		ContainerStyle.Render(
 			lipgloss.JoinVertical(
 				lipgloss.Left,
-				TitleStyle.Render("Create new download"),
+				TitleStyle.Render(title),
 				FormStyle.Render(
 					lipgloss.JoinVertical(lipgloss.Left,
-						m.videoId.View(),
-						m.outputName.View(),
-						m.audioFormat.View(),
-						m.extraCommands.View(),
+						source,
+						m.fieldView(fieldAudioFormat),
+						m.fieldView(fieldExtraCommands),
+						m.fieldView(fieldPriority),
+						m.fieldView(fieldPreset),
+						m.fieldView(fieldNotBefore),
+						m.fieldView(fieldRateLimit),
+						m.fieldView(fieldTags),
 					),
 				),
+				TitleStyle.Render("Download options"),
+				FormStyle.Render(m.optionFieldsView()),
 				TitleStyle.Render("Youtube-dl options"),
 				FormStyle.Render(
 					lipgloss.JoinVertical(lipgloss.Left,
@@ -248,6 +659,13 @@ func (m FormModel) View() string {
 						),
 					),
 				),
+				FormStyle.Render(
+					lipgloss.JoinVertical(lipgloss.Left,
+						summary,
+						WarningStyle.Render(m.errorMessage),
+						WarningStyle.Render(m.duplicateWarning),
+					),
+				),
 			),
 		),
 		HelpContainerStyle.Render(
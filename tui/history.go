@@ -0,0 +1,375 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jim-at-jibba/telecharger/data"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+/* HISTORY MODEL */
+type HistoryKeyMap struct {
+	Next    key.Binding
+	Requeue key.Binding
+	Open    key.Binding
+	Copy    key.Binding
+	Back    key.Binding
+	Quit    key.Binding
+}
+
+var DefaultHistoryKeyMap = HistoryKeyMap{
+	Next: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "go to next filter/results"),
+	),
+	Requeue: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "download again"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open file"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy URL"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// history filters, in tab order, followed by the results
+const (
+	historyText = iota
+	historyFrom
+	historyTo
+	historyStatus
+	historyPreset
+	historyMedia
+	historyResults
+)
+
+// historyLimit is the most results a search shows.
+const historyLimit = 200
+
+// historyDateLayout is the format of the date filters.
+const historyDateLayout = "2006-01-02"
+
+type HistoryItem struct {
+	item *data.QueueItem
+}
+
+func (i HistoryItem) Title() string {
+	if len(i.item.Title) > 0 {
+		return i.item.Title
+	}
+	return newQueueItemFromData(i.item).name()
+}
+func (i HistoryItem) Description() string {
+	parts := []string{i.item.Status}
+	if i.item.CompletedAt.Valid {
+		parts = append(parts, i.item.CompletedAt.Time.Local().Format("2006-01-02 15:04"))
+	} else if i.item.StartedAt.Valid {
+		parts = append(parts, "started "+i.item.StartedAt.Time.Local().Format("2006-01-02 15:04"))
+	}
+	if len(i.item.Uploader) > 0 {
+		parts = append(parts, i.item.Uploader)
+	}
+	if len(i.item.Preset) > 0 {
+		parts = append(parts, "preset "+i.item.Preset)
+	}
+	if i.item.Options.Bool(ytdlp.AudioOnly) {
+		parts = append(parts, "audio")
+	}
+	if len(i.item.ErrorMessage) > 0 {
+		parts = append(parts, i.item.ErrorMessage)
+	}
+	if i.item.ArchivedAt.Valid {
+		parts = append(parts, "archived")
+	}
+	return strings.Join(parts, " • ")
+}
+func (i HistoryItem) FilterValue() string { return i.Title() }
+
+type HistoryModel struct {
+	appConfig     utils.Config
+	inputs        []textinput.Model
+	focused       int
+	list          list.Model
+	status        string
+	width, height int
+}
+
+func NewHistory(cfg utils.Config, width, height int) *HistoryModel {
+	m := &HistoryModel{appConfig: cfg, width: width, height: height}
+
+	d := list.NewDefaultDelegate()
+	c := lipgloss.Color("6")
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(c).BorderLeftForeground(c)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle.Copy()
+
+	m.list = list.New([]list.Item{}, d, width-10, height/2)
+	m.list.SetShowHelp(false)
+	m.list.SetFilteringEnabled(false)
+	m.list.Title = "History"
+	m.list.Styles.Title = ListTitle
+	m.list.Styles.ActivePaginationDot = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+
+	placeholders := []string{
+		"Search titles, uploaders, URLs, names and tags",
+		"From (YYYY-MM-DD)",
+		"To (YYYY-MM-DD)",
+		fmt.Sprintf("Status (%s)", strings.Join(data.Statuses, ", ")),
+		"Preset",
+		fmt.Sprintf("Media (%s, %s)", data.AudioMedia, data.VideoMedia),
+	}
+	for _, placeholder := range placeholders {
+		input := textinput.New()
+		input.Placeholder = placeholder
+		m.inputs = append(m.inputs, input)
+	}
+	m.inputs[historyText].Focus()
+	m.search()
+
+	return m
+}
+
+// filter reads the filters, or returns the problem with one of them.
+func (m *HistoryModel) filter() (data.HistoryFilter, error) {
+	filter := data.HistoryFilter{
+		Text:  m.inputs[historyText].Value(),
+		Limit: historyLimit,
+	}
+
+	for i, target := range map[int]*time.Time{historyFrom: &filter.From, historyTo: &filter.To} {
+		value := strings.TrimSpace(m.inputs[i].Value())
+		if len(value) == 0 {
+			continue
+		}
+		date, err := time.ParseInLocation(historyDateLayout, value, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+		}
+		*target = date
+	}
+
+	for _, status := range strings.Split(m.inputs[historyStatus].Value(), ",") {
+		status = strings.TrimSpace(status)
+		if len(status) == 0 {
+			continue
+		}
+		known := false
+		for _, s := range data.Statuses {
+			known = known || s == status
+		}
+		if !known {
+			return filter, fmt.Errorf("unknown status %q, expected one of %s", status, strings.Join(data.Statuses, ", "))
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	filter.Preset = strings.TrimSpace(m.inputs[historyPreset].Value())
+	if _, ok := m.appConfig.Preset(filter.Preset); len(filter.Preset) > 0 && !ok {
+		return filter, fmt.Errorf("unknown preset %q", filter.Preset)
+	}
+
+	filter.Media = strings.TrimSpace(m.inputs[historyMedia].Value())
+	if len(filter.Media) > 0 && filter.Media != data.AudioMedia && filter.Media != data.VideoMedia {
+		return filter, fmt.Errorf("unknown media %q, expected %s or %s", filter.Media, data.AudioMedia, data.VideoMedia)
+	}
+
+	return filter, nil
+}
+
+// search runs the search for the current filters.
+func (m *HistoryModel) search() {
+	filter, err := m.filter()
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+
+	items, err := data.SearchHistory(filter)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+
+	results := []list.Item{}
+	for _, item := range items {
+		results = append(results, HistoryItem{item: item})
+	}
+	m.list.SetItems(results)
+	m.status = fmt.Sprintf("%d results", len(items))
+	if len(items) == historyLimit {
+		m.status = fmt.Sprintf("showing the first %d results", historyLimit)
+	}
+}
+
+// focus moves the cursor to a filter, or to the results.
+func (m *HistoryModel) focus(i int) tea.Cmd {
+	if m.focused < historyResults {
+		m.inputs[m.focused].Blur()
+	}
+	m.focused = i
+	if i < historyResults {
+		return m.inputs[i].Focus()
+	}
+	return nil
+}
+
+// requeue puts the selected download back on the queue.
+func (m *HistoryModel) requeue(item *data.QueueItem) tea.Cmd {
+	if item.Status == "downloading" || item.Status == "queued" {
+		m.status = fmt.Sprintf("%s is already %s", newQueueItemFromData(item).name(), item.Status)
+		return nil
+	}
+	if err := data.RequeueQueueItem(item.Id); err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	m.search()
+	m.status = fmt.Sprintf("queued %s again", newQueueItemFromData(item).name())
+	return func() tea.Msg { return listsChanged{} }
+}
+
+// openFile opens the downloaded file with the system's default application.
+func (m *HistoryModel) openFile(item *data.QueueItem) {
+	if len(item.FilePath) == 0 {
+		m.status = "yt-dlp didn't report where this download was saved"
+		return
+	}
+	if _, err := os.Stat(item.FilePath); err != nil {
+		m.status = fmt.Sprintf("can't open %s: %v", item.FilePath, err)
+		return
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", item.FilePath)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", item.FilePath)
+	default:
+		cmd = exec.Command("xdg-open", item.FilePath)
+	}
+	if err := cmd.Start(); err != nil {
+		m.status = fmt.Sprintf("can't open %s: %v", item.FilePath, err)
+		return
+	}
+	go cmd.Wait()
+	m.status = fmt.Sprintf("opened %s", item.FilePath)
+}
+
+// copyURL copies the selected download's URL to the clipboard.
+func (m *HistoryModel) copyURL(item *data.QueueItem) {
+	if err := clipboard.WriteAll(item.VideoId); err != nil {
+		m.status = fmt.Sprintf("can't copy to the clipboard: %v", err)
+		return
+	}
+	m.status = fmt.Sprintf("copied %s", item.VideoId)
+}
+
+func (m *HistoryModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HistoryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	dashboardCmd := forwardToDashboard(msg)
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, dashboardCmd)
+}
+
+func (m *HistoryModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.list.SetSize(msg.Width-10, msg.Height/2)
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, DefaultHistoryKeyMap.Quit):
+			return Models[Info], nil
+		case key.Matches(msg, DefaultHistoryKeyMap.Back):
+			Models[History] = m
+			return Models[Info], nil
+		case key.Matches(msg, DefaultHistoryKeyMap.Next):
+			return m, m.focus((m.focused + 1) % (historyResults + 1))
+		}
+
+		if m.focused < historyResults {
+			before := m.inputs[m.focused].Value()
+			m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+			if m.inputs[m.focused].Value() != before {
+				m.search()
+			}
+			return m, cmd
+		}
+
+		selected, ok := m.list.SelectedItem().(HistoryItem)
+		switch {
+		case !ok:
+		case key.Matches(msg, DefaultHistoryKeyMap.Requeue):
+			return m, m.requeue(selected.item)
+		case key.Matches(msg, DefaultHistoryKeyMap.Open):
+			m.openFile(selected.item)
+			return m, nil
+		case key.Matches(msg, DefaultHistoryKeyMap.Copy):
+			m.copyURL(selected.item)
+			return m, nil
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m *HistoryModel) filtersView() string {
+	fields := []string{}
+	for _, input := range m.inputs {
+		fields = append(fields, input.View())
+	}
+	return FormStyle.Render(lipgloss.JoinVertical(lipgloss.Left, fields...))
+}
+
+func (m *HistoryModel) helpView() string {
+	help := "\n tab: next filter/results • r: download again • o: open file • y: copy URL • esc: back • ctrl+c: quit\n"
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(help)
+}
+
+func (m *HistoryModel) View() string {
+	oneWide := int(float64(m.width - 8))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		ContainerStyle.Width(oneWide).Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				TitleStyle.Render("Search history"),
+				m.filtersView(),
+				FormStyle.Render(ListViewStyle.Render(m.list.View())),
+				FormStyle.Render(WarningStyle.Render(m.status)),
+			),
+		),
+		HelpContainerStyle.Width(oneWide).Render(
+			m.helpView(),
+		),
+	)
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jim-at-jibba/telecharger/data"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+	"github.com/jim-at-jibba/telecharger/ytdlp"
+)
+
+// openProfilePicker lists the profiles to switch to.
+func (m *model) openProfilePicker() {
+	if m.downloading || m.checkingSources {
+		m.profileStatus = "wait for the running download and checks to finish before switching profile"
+		return
+	}
+
+	profiles, err := utils.Profiles()
+	if err != nil {
+		m.profileStatus = err.Error()
+	}
+	m.profiles = profiles
+	m.profilePicker = true
+	m.profileChoice = 0
+	for i, name := range profiles {
+		if name == utils.Profile() {
+			m.profileChoice = i
+		}
+	}
+}
+
+func (m *model) updateProfilePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, DefaultKeyMap.Up):
+		if m.profileChoice > 0 {
+			m.profileChoice--
+		}
+	case key.Matches(msg, DefaultKeyMap.Down):
+		if m.profileChoice < len(m.profiles)-1 {
+			m.profileChoice++
+		}
+	case key.Matches(msg, DefaultKeyMap.Back):
+		m.profilePicker = false
+	case key.Matches(msg, DefaultKeyMap.Enter):
+		m.profilePicker = false
+		if name := m.profiles[m.profileChoice]; name != utils.Profile() {
+			return m.switchProfile(name)
+		}
+	}
+	return m, nil
+}
+
+// switchProfile reopens telecharger with the config and database of another
+// profile, staying on the current one if its config or database can't be read.
+func (m *model) switchProfile(name string) (tea.Model, tea.Cmd) {
+	previous := utils.Profile()
+	if err := utils.SetProfile(name); err != nil {
+		m.profileStatus = err.Error()
+		return m, nil
+	}
+
+	cfg, err := utils.ParseConfig()
+	if err != nil {
+		_ = utils.SetProfile(previous)
+		m.profileStatus = fmt.Sprintf("can't switch to %s: %v", name, err)
+		return m, nil
+	}
+
+	// the current database stays open when the profile's can't be opened
+	if err := data.Open(cfg.Settings); err != nil {
+		_ = utils.SetProfile(previous)
+		m.profileStatus = fmt.Sprintf("can't switch to %s: %v", name, err)
+		return m, nil
+	}
+	if len(cfg.Settings.YtdlpPath) > 0 {
+		ytdlp.Binary = cfg.Settings.YtdlpPath
+	}
+
+	Models = NewModels(cfg, m.width, m.height)
+	dashboard := Models[Info].(*model)
+
+	// the tick that polls subscriptions keeps running, so only the first check is started here
+	width, height := m.width, m.height
+	return dashboard, tea.Batch(dashboard.checkSources(), func() tea.Msg {
+		return tea.WindowSizeMsg{Width: width, Height: height}
+	})
+}
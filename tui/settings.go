@@ -0,0 +1,562 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jim-at-jibba/telecharger/diskspace"
+	"github.com/jim-at-jibba/telecharger/ratelimit"
+	"github.com/jim-at-jibba/telecharger/schedule"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+/* SETTINGS MODEL */
+type SettingsKeyMap struct {
+	Next   key.Binding
+	Prev   key.Binding
+	Toggle key.Binding
+	Save   key.Binding
+	Back   key.Binding
+	Quit   key.Binding
+}
+
+var DefaultSettingsKeyMap = SettingsKeyMap{
+	Next: key.NewBinding(
+		key.WithKeys("tab", "enter", "down"),
+		key.WithHelp("tab", "go to next field"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab", "go to previous field"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "toggle option"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "save"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "go back without saving"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// settings fields, in tab order
+const (
+	settingsEnableLogging = iota
+	settingsDownloadFolder
+	settingsMinFreeSpace
+	settingsDatabasePath
+	settingsDownloadArchive
+	settingsYtdlpPath
+	settingsAutoStart
+	settingsDefaultPreset
+	settingsWatchClipboard
+	settingsTagFolders
+	settingsHistoryKeepItems
+	settingsHistoryKeepDays
+	settingsHistoryArchive
+	settingsSubscriptionInterval
+	settingsFeedInterval
+	settingsDownloadWindows
+	settingsRateLimit
+	settingsRateLimitSchedule
+	settingsPreset
+	settingsProxy
+	settingsProxyRules
+	settingsCookiesFile
+	settingsCookiesFromBrowser
+	settingsUserAgent
+	settingsSourceAddress
+	settingsFieldCount
+)
+
+// settingsLabels are shown next to each field.
+var settingsLabels = []string{
+	"Enable logging",
+	"Download folder",
+	"Minimum free space",
+	"Database path",
+	"Download archive",
+	"yt-dlp path",
+	"Auto start",
+	"Default preset",
+	"Watch clipboard",
+	"Tag folders",
+	"Keep done items",
+	"Keep done days",
+	"Archive old items",
+	"Subscription interval",
+	"Feed interval",
+	"Download windows",
+	"Rate limit",
+	"Rate limit schedule",
+	"Network preset",
+	"Proxy",
+	"Proxy rules",
+	"Cookies file",
+	"Cookies from browser",
+	"User agent",
+	"Source address",
+}
+
+// settingsPlaceholders describe the text fields.
+var settingsPlaceholders = map[int]string{
+	settingsDownloadFolder:       "Folder downloads are saved to",
+	settingsMinFreeSpace:         "2G, downloads pause below it, empty for none",
+	settingsDatabasePath:         "Empty for the data directory",
+	settingsYtdlpPath:            "yt-dlp",
+	settingsDefaultPreset:        "Preset for URLs queued from the clipboard",
+	settingsHistoryKeepItems:     "Most recent items on the Done list, 0 keeps all",
+	settingsHistoryKeepDays:      "Days items stay on the Done list, 0 keeps all",
+	settingsSubscriptionInterval: "Minutes, 0 turns checks off",
+	settingsFeedInterval:         "Minutes, 0 turns checks off",
+	settingsDownloadWindows:      "01:00-07:00 mon-fri; 00:00-23:59 sat-sun",
+	settingsRateLimit:            "500K, 2M, empty for no limit",
+	settingsRateLimitSchedule:    "09:00-18:00 mon-fri=300K; 01:00-07:00=0",
+	settingsPreset:               "Preset to override, empty for every download",
+	settingsProxy:                "socks5://127.0.0.1:1080",
+	settingsProxyRules:           "example.com=http://proxy:3128, other.org=direct",
+	settingsCookiesFile:          "~/cookies.txt",
+	settingsCookiesFromBrowser:   "firefox, chrome:Profile 1",
+	settingsUserAgent:            "Mozilla/5.0",
+	settingsSourceAddress:        "IP to bind to",
+}
+
+// configChanged tells the dashboard to use a config that was saved from another screen.
+type configChanged struct {
+	config utils.Config
+}
+
+type SettingsModel struct {
+	appConfig     utils.Config
+	inputs        []textinput.Model
+	toggles       map[int]bool
+	focusedInput  int
+	status        string
+	width, height int
+}
+
+func NewSettings(cfg utils.Config, width, height int) *SettingsModel {
+	m := &SettingsModel{appConfig: cfg, width: width, height: height, toggles: map[int]bool{}}
+
+	for i := 0; i < settingsFieldCount; i++ {
+		input := textinput.New()
+		input.Placeholder = settingsPlaceholders[i]
+		input.Prompt = ""
+		m.inputs = append(m.inputs, input)
+	}
+
+	settings := cfg.Settings
+	m.toggles[settingsEnableLogging] = settings.EnableLogging
+	m.toggles[settingsDownloadArchive] = settings.DownloadArchive
+	m.toggles[settingsAutoStart] = settings.AutoStart
+	m.toggles[settingsWatchClipboard] = settings.WatchClipboard
+	m.toggles[settingsTagFolders] = settings.TagFolders
+	m.toggles[settingsHistoryArchive] = settings.HistoryArchive
+	m.inputs[settingsDownloadFolder].SetValue(settings.DownloadFolder)
+	m.inputs[settingsMinFreeSpace].SetValue(settings.MinFreeSpace)
+	m.inputs[settingsDatabasePath].SetValue(settings.DatabasePath)
+	m.inputs[settingsYtdlpPath].SetValue(settings.YtdlpPath)
+	m.inputs[settingsDefaultPreset].SetValue(settings.DefaultPreset)
+	m.inputs[settingsHistoryKeepItems].SetValue(strconv.Itoa(settings.HistoryKeepItems))
+	m.inputs[settingsHistoryKeepDays].SetValue(strconv.Itoa(settings.HistoryKeepDays))
+	m.inputs[settingsSubscriptionInterval].SetValue(strconv.Itoa(settings.SubscriptionInterval))
+	m.inputs[settingsFeedInterval].SetValue(strconv.Itoa(settings.FeedInterval))
+	m.inputs[settingsDownloadWindows].SetValue(strings.Join(settings.DownloadWindows, "; "))
+	m.inputs[settingsRateLimit].SetValue(settings.RateLimit)
+	periods := []string{}
+	for _, period := range settings.RateLimitSchedule {
+		periods = append(periods, period.Window+"="+period.Limit)
+	}
+	m.inputs[settingsRateLimitSchedule].SetValue(strings.Join(periods, "; "))
+	m.loadNetwork()
+
+	m.focus(settingsEnableLogging)
+	return m
+}
+
+// isToggle reports whether field is switched on and off rather than typed.
+func isToggle(field int) bool {
+	return field == settingsEnableLogging || field == settingsDownloadArchive || field == settingsAutoStart || field == settingsWatchClipboard ||
+		field == settingsTagFolders || field == settingsHistoryArchive
+}
+
+// focus moves the cursor to field.
+func (m *SettingsModel) focus(field int) tea.Cmd {
+	m.inputs[m.focusedInput].Blur()
+	m.focusedInput = field
+	if isToggle(field) {
+		return nil
+	}
+	m.inputs[field].Focus()
+	return textinput.Blink
+}
+
+// network returns the options stored for the preset in the network preset field.
+func (m *SettingsModel) network() (utils.NetworkConfig, error) {
+	name := strings.TrimSpace(m.inputs[settingsPreset].Value())
+	if len(name) == 0 {
+		return m.appConfig.Settings.Network, nil
+	}
+	preset, ok := m.appConfig.Preset(name)
+	if !ok {
+		return utils.NetworkConfig{}, fmt.Errorf("unknown preset %q", name)
+	}
+	return preset.Network, nil
+}
+
+// loadNetwork fills the network fields with the options of the chosen preset.
+func (m *SettingsModel) loadNetwork() {
+	network, err := m.network()
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = ""
+
+	rules := []string{}
+	for _, rule := range network.ProxyRules {
+		rules = append(rules, rule.Domain+"="+rule.Proxy)
+	}
+
+	m.inputs[settingsProxy].SetValue(network.Proxy)
+	m.inputs[settingsProxyRules].SetValue(strings.Join(rules, ", "))
+	m.inputs[settingsCookiesFile].SetValue(network.CookiesFile)
+	m.inputs[settingsCookiesFromBrowser].SetValue(network.CookiesFromBrowser)
+	m.inputs[settingsUserAgent].SetValue(network.UserAgent)
+	m.inputs[settingsSourceAddress].SetValue(network.SourceAddress)
+}
+
+// splitList splits a field holding several values separated by sep.
+func splitList(value, sep string) []string {
+	values := []string{}
+	for _, part := range strings.Split(value, sep) {
+		if part = strings.TrimSpace(part); len(part) > 0 {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseProxyRules reads rules written as "domain=proxy" separated by commas.
+func parseProxyRules(value string) ([]utils.ProxyRule, error) {
+	var rules []utils.ProxyRule
+	for _, part := range splitList(value, ",") {
+		domain, proxy, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid proxy rule %q, expected domain=proxy", part)
+		}
+		rules = append(rules, utils.ProxyRule{Domain: strings.TrimSpace(domain), Proxy: strings.TrimSpace(proxy)})
+	}
+	return rules, nil
+}
+
+// parseInterval reads a number of minutes that can't be negative.
+func parseInterval(label, value string) (int, error) {
+	minutes, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("%s must be a number of minutes, 0 or more", strings.ToLower(label))
+	}
+	return minutes, nil
+}
+
+// parseCount reads a number of items or days, where 0 means no limit.
+func parseCount(label, value string) (int, error) {
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("%s must be a whole number, 0 or more", strings.ToLower(label))
+	}
+	return count, nil
+}
+
+// settingsFromFields reads and validates the general fields.
+func (m *SettingsModel) settingsFromFields() (utils.SettingsConfig, error) {
+	settings := m.appConfig.Settings
+	var err error
+
+	settings.EnableLogging = m.toggles[settingsEnableLogging]
+	settings.DownloadArchive = m.toggles[settingsDownloadArchive]
+	settings.AutoStart = m.toggles[settingsAutoStart]
+	settings.WatchClipboard = m.toggles[settingsWatchClipboard]
+	settings.TagFolders = m.toggles[settingsTagFolders]
+	settings.HistoryArchive = m.toggles[settingsHistoryArchive]
+
+	settings.DownloadFolder = strings.TrimSpace(m.inputs[settingsDownloadFolder].Value())
+	if err := utils.ValidateDownloadFolder(settings.DownloadFolder); err != nil {
+		return settings, err
+	}
+
+	settings.MinFreeSpace = strings.TrimSpace(m.inputs[settingsMinFreeSpace].Value())
+	if _, err := diskspace.ParseSize(settings.MinFreeSpace); err != nil {
+		return settings, fmt.Errorf("minimum free space: %v", err)
+	}
+
+	settings.DatabasePath = strings.TrimSpace(m.inputs[settingsDatabasePath].Value())
+	if len(settings.DatabasePath) > 0 {
+		if info, err := os.Stat(filepath.Dir(utils.ExpandHome(settings.DatabasePath))); err != nil || !info.IsDir() {
+			return settings, fmt.Errorf("database path: the folder for %s doesn't exist", settings.DatabasePath)
+		}
+	}
+
+	// only checked when changed, so other settings can be saved on a machine
+	// without yt-dlp
+	settings.YtdlpPath = strings.TrimSpace(m.inputs[settingsYtdlpPath].Value())
+	if settings.YtdlpPath != m.appConfig.Settings.YtdlpPath {
+		if _, err := exec.LookPath(utils.ExpandHome(settings.YtdlpPath)); err != nil {
+			return settings, fmt.Errorf("yt-dlp path: %v", err)
+		}
+	}
+
+	settings.DefaultPreset = strings.TrimSpace(m.inputs[settingsDefaultPreset].Value())
+	if _, ok := m.appConfig.Preset(settings.DefaultPreset); !ok && len(settings.DefaultPreset) > 0 {
+		return settings, fmt.Errorf("default preset: unknown preset %q", settings.DefaultPreset)
+	}
+
+	if settings.HistoryKeepItems, err = parseCount(settingsLabels[settingsHistoryKeepItems], m.inputs[settingsHistoryKeepItems].Value()); err != nil {
+		return settings, err
+	}
+	if settings.HistoryKeepDays, err = parseCount(settingsLabels[settingsHistoryKeepDays], m.inputs[settingsHistoryKeepDays].Value()); err != nil {
+		return settings, err
+	}
+	if settings.SubscriptionInterval, err = parseInterval(settingsLabels[settingsSubscriptionInterval], m.inputs[settingsSubscriptionInterval].Value()); err != nil {
+		return settings, err
+	}
+	if settings.FeedInterval, err = parseInterval(settingsLabels[settingsFeedInterval], m.inputs[settingsFeedInterval].Value()); err != nil {
+		return settings, err
+	}
+
+	settings.DownloadWindows = splitList(m.inputs[settingsDownloadWindows].Value(), ";")
+	if _, err := schedule.Parse(settings.DownloadWindows); err != nil {
+		return settings, err
+	}
+
+	settings.RateLimit = strings.TrimSpace(m.inputs[settingsRateLimit].Value())
+	settings.RateLimitSchedule = []utils.RateLimitPeriod{}
+	for _, part := range splitList(m.inputs[settingsRateLimitSchedule].Value(), ";") {
+		window, limit, found := strings.Cut(part, "=")
+		if !found {
+			return settings, fmt.Errorf("invalid rate limit period %q, expected window=limit", part)
+		}
+		settings.RateLimitSchedule = append(settings.RateLimitSchedule, utils.RateLimitPeriod{Window: strings.TrimSpace(window), Limit: strings.TrimSpace(limit)})
+	}
+	if _, err := ratelimit.New(settings); err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// networkFromFields reads and validates the network fields.
+func (m *SettingsModel) networkFromFields() (utils.NetworkConfig, error) {
+	rules, err := parseProxyRules(m.inputs[settingsProxyRules].Value())
+	if err != nil {
+		return utils.NetworkConfig{}, err
+	}
+
+	network := utils.NetworkConfig{
+		Proxy:              strings.TrimSpace(m.inputs[settingsProxy].Value()),
+		ProxyRules:         rules,
+		CookiesFile:        strings.TrimSpace(m.inputs[settingsCookiesFile].Value()),
+		CookiesFromBrowser: strings.TrimSpace(m.inputs[settingsCookiesFromBrowser].Value()),
+		UserAgent:          strings.TrimSpace(m.inputs[settingsUserAgent].Value()),
+		SourceAddress:      strings.TrimSpace(m.inputs[settingsSourceAddress].Value()),
+	}
+	return network, network.Validate()
+}
+
+// save validates every field and writes the ones that changed to the config
+// file, then hands the new config to the dashboard.
+func (m *SettingsModel) save() tea.Cmd {
+	settings, err := m.settingsFromFields()
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	network, err := m.networkFromFields()
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	cfg := m.appConfig
+	values := []utils.ConfigValue{}
+	changed := func(key string, old, value interface{}) {
+		if !reflect.DeepEqual(old, value) {
+			values = append(values, utils.ConfigValue{Keys: []string{"settings", key}, Value: value})
+		}
+	}
+	changed("enable_logging", cfg.Settings.EnableLogging, settings.EnableLogging)
+	changed("download_folder", cfg.Settings.DownloadFolder, settings.DownloadFolder)
+	changed("min_free_space", cfg.Settings.MinFreeSpace, settings.MinFreeSpace)
+	changed("database_path", cfg.Settings.DatabasePath, settings.DatabasePath)
+	changed("download_archive", cfg.Settings.DownloadArchive, settings.DownloadArchive)
+	changed("ytdlp_path", cfg.Settings.YtdlpPath, settings.YtdlpPath)
+	changed("auto_start", cfg.Settings.AutoStart, settings.AutoStart)
+	changed("default_preset", cfg.Settings.DefaultPreset, settings.DefaultPreset)
+	changed("watch_clipboard", cfg.Settings.WatchClipboard, settings.WatchClipboard)
+	changed("tag_folders", cfg.Settings.TagFolders, settings.TagFolders)
+	changed("history_keep_items", cfg.Settings.HistoryKeepItems, settings.HistoryKeepItems)
+	changed("history_keep_days", cfg.Settings.HistoryKeepDays, settings.HistoryKeepDays)
+	changed("history_archive", cfg.Settings.HistoryArchive, settings.HistoryArchive)
+	changed("subscription_interval", cfg.Settings.SubscriptionInterval, settings.SubscriptionInterval)
+	changed("feed_interval", cfg.Settings.FeedInterval, settings.FeedInterval)
+	if len(cfg.Settings.DownloadWindows) > 0 || len(settings.DownloadWindows) > 0 {
+		changed("download_windows", cfg.Settings.DownloadWindows, settings.DownloadWindows)
+	}
+	changed("rate_limit", cfg.Settings.RateLimit, settings.RateLimit)
+	if len(cfg.Settings.RateLimitSchedule) > 0 || len(settings.RateLimitSchedule) > 0 {
+		changed("rate_limit_schedule", cfg.Settings.RateLimitSchedule, settings.RateLimitSchedule)
+	}
+	cfg.Settings = settings
+
+	name := strings.TrimSpace(m.inputs[settingsPreset].Value())
+	if len(name) == 0 {
+		changed("network", cfg.Settings.Network, network)
+		cfg.Settings.Network = network
+	} else {
+		presets := append([]utils.PresetConfig{}, cfg.Presets...)
+		for i := range presets {
+			if presets[i].Name == name {
+				presets[i].Network = network
+			}
+		}
+		if !reflect.DeepEqual(presets, cfg.Presets) {
+			values = append(values, utils.ConfigValue{Keys: []string{"presets"}, Value: presets})
+		}
+		cfg.Presets = presets
+	}
+
+	if len(values) == 0 {
+		m.status = "nothing to save"
+		return nil
+	}
+	if err := utils.SetConfigValues(values...); err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	m.appConfig = cfg
+	m.status = "saved to " + utils.ConfigFileName
+	return func() tea.Msg { return configChanged{config: cfg} }
+}
+
+func (m *SettingsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	dashboardCmd := forwardToDashboard(msg)
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, dashboardCmd)
+}
+
+func (m *SettingsModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, DefaultSettingsKeyMap.Quit):
+			return Models[Info], nil
+		case key.Matches(msg, DefaultSettingsKeyMap.Back):
+			Models[Settings] = m
+			return Models[Info], nil
+		case key.Matches(msg, DefaultSettingsKeyMap.Save):
+			return m, m.save()
+		case key.Matches(msg, DefaultSettingsKeyMap.Next, DefaultSettingsKeyMap.Prev):
+			if m.focusedInput == settingsPreset {
+				if _, err := m.network(); err != nil {
+					m.status = err.Error()
+					return m, nil
+				}
+				m.loadNetwork()
+			}
+			next := m.focusedInput + 1
+			if key.Matches(msg, DefaultSettingsKeyMap.Prev) {
+				next = m.focusedInput - 1 + settingsFieldCount
+			}
+			return m, m.focus(next % settingsFieldCount)
+		case isToggle(m.focusedInput) && key.Matches(msg, DefaultSettingsKeyMap.Toggle):
+			m.toggles[m.focusedInput] = !m.toggles[m.focusedInput]
+			return m, nil
+		}
+		if !isToggle(m.focusedInput) {
+			m.inputs[m.focusedInput], cmd = m.inputs[m.focusedInput].Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// fieldView renders a single field with its label.
+func (m *SettingsModel) fieldView(field int) string {
+	label := fmt.Sprintf("%-22s", settingsLabels[field])
+	if field == m.focusedInput {
+		label = ActiveStyle.Render(label)
+	} else {
+		label = InactiveStyle.Render(label)
+	}
+
+	if isToggle(field) {
+		box := "[ ]"
+		if m.toggles[field] {
+			box = "[x]"
+		}
+		if field == m.focusedInput {
+			box = CheckboxCheckedStyle.Render(box)
+		}
+		return label + box
+	}
+	return label + m.inputs[field].View()
+}
+
+func (m *SettingsModel) helpView() string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("\n tab/↓: next field • shift+tab/↑: previous field • space: toggle • ctrl+s: save • esc: back • ctrl+c: quit\n Lists are separated by ; and saved settings apply straight away. Presets only need the network options that differ from every download's.\n")
+}
+
+func (m *SettingsModel) View() string {
+	oneWide := int(float64(m.width - 8))
+
+	general := []string{}
+	for field := settingsEnableLogging; field < settingsPreset; field++ {
+		general = append(general, m.fieldView(field))
+	}
+	network := []string{}
+	for field := settingsPreset; field < settingsFieldCount; field++ {
+		network = append(network, m.fieldView(field))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		ContainerStyle.Width(oneWide).Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				TitleStyle.Render("Settings"),
+				FormStyle.Render(lipgloss.JoinVertical(lipgloss.Left, general...)),
+				TitleStyle.Render("Network"),
+				FormStyle.Render(lipgloss.JoinVertical(lipgloss.Left, network...)),
+				FormStyle.Render(WarningStyle.Render(m.status)),
+			),
+		),
+		HelpContainerStyle.Width(oneWide).Render(
+			m.helpView(),
+		),
+	)
+}
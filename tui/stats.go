@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jim-at-jibba/telecharger/stats"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+/* STATS MODEL */
+type StatsKeyMap struct {
+	Refresh key.Binding
+	Back    key.Binding
+	Quit    key.Binding
+}
+
+var DefaultStatsKeyMap = StatsKeyMap{
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+type StatsModel struct {
+	appConfig     utils.Config
+	report        stats.Report
+	viewport      viewport.Model
+	status        string
+	width, height int
+}
+
+func NewStats(cfg utils.Config, width, height int) *StatsModel {
+	m := &StatsModel{appConfig: cfg, width: width, height: height}
+	m.viewport = viewport.New(width-12, height-10)
+	m.refresh()
+	return m
+}
+
+// refresh collects the statistics again and redraws them.
+func (m *StatsModel) refresh() {
+	report, err := stats.Collect(time.Now(), stats.DefaultDays, stats.DefaultWeeks)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.report = report
+	m.status = "updated " + time.Now().Format("15:04")
+	m.viewport.SetContent(stats.Render(m.report, m.width-12))
+}
+
+func (m *StatsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *StatsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	dashboardCmd := forwardToDashboard(msg)
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, dashboardCmd)
+}
+
+func (m *StatsModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.viewport.Width, m.viewport.Height = msg.Width-12, msg.Height-10
+		m.viewport.SetContent(stats.Render(m.report, m.width-12))
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, DefaultStatsKeyMap.Quit):
+			return Models[Info], nil
+		case key.Matches(msg, DefaultStatsKeyMap.Back):
+			Models[Stats] = m
+			return Models[Info], nil
+		case key.Matches(msg, DefaultStatsKeyMap.Refresh):
+			m.refresh()
+			return m, nil
+		}
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *StatsModel) helpView() string {
+	help := "\n ↑/↓: scroll • r: refresh • esc: back • ctrl+c: quit\n"
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(help)
+}
+
+func (m *StatsModel) View() string {
+	oneWide := int(float64(m.width - 8))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		ContainerStyle.Width(oneWide).Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				TitleStyle.Render("Statistics"),
+				FormStyle.Render(m.viewport.View()),
+				FormStyle.Render(InactiveStyle.Render(m.status)),
+			),
+		),
+		HelpContainerStyle.Width(oneWide).Render(
+			m.helpView(),
+		),
+	)
+}
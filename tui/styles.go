@@ -35,6 +35,7 @@ var (
 	InactiveStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
 	ActiveStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
 	CheckboxCheckedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	WarningStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
 	OptionsViewStyle     = lipgloss.NewStyle()
 
 	DialogBoxStyle = lipgloss.NewStyle().
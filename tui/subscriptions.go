@@ -0,0 +1,335 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/subscriptions"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+/* SUBSCRIPTIONS MODEL */
+type SubscriptionsKeyMap struct {
+	New    key.Binding
+	Delete key.Binding
+	Check  key.Binding
+	Tab    key.Binding
+	Back   key.Binding
+	Quit   key.Binding
+}
+
+var DefaultSubscriptionsKeyMap = SubscriptionsKeyMap{
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new subscription"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete subscription"),
+	),
+	Check: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "check now"),
+	),
+	Tab: key.NewBinding(
+		key.WithKeys("tab", "enter"),
+		key.WithHelp("tab", "go to next field/submit"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// subscription fields of the add form, in tab order
+const (
+	subscriptionUrl = iota
+	subscriptionPreset
+	subscriptionTitle
+	subscriptionMinDuration
+	subscriptionDateAfter
+)
+
+type SubscriptionItem struct {
+	subscription *data.Subscription
+}
+
+func (i SubscriptionItem) Title() string { return i.subscription.Url }
+func (i SubscriptionItem) Description() string {
+	parts := []string{}
+	if len(i.subscription.Preset) > 0 {
+		parts = append(parts, "preset "+i.subscription.Preset)
+	}
+	if len(i.subscription.TitleRegex) > 0 {
+		parts = append(parts, "title ~ "+i.subscription.TitleRegex)
+	}
+	if i.subscription.MinDuration > 0 {
+		parts = append(parts, fmt.Sprintf("≥ %ds", i.subscription.MinDuration))
+	}
+	if len(i.subscription.DateAfter) > 0 {
+		parts = append(parts, "after "+i.subscription.DateAfter)
+	}
+	if i.subscription.LastChecked.Valid {
+		parts = append(parts, "checked "+i.subscription.LastChecked.Time.Local().Format("2006-01-02 15:04"))
+	} else {
+		parts = append(parts, "never checked")
+	}
+	return strings.Join(parts, " • ")
+}
+func (i SubscriptionItem) FilterValue() string { return i.subscription.Url }
+
+// subscriptionChecked reports the result of checking a single subscription.
+type subscriptionChecked struct {
+	url   string
+	added int
+	err   error
+}
+
+type SubscriptionsModel struct {
+	appConfig     utils.Config
+	list          list.Model
+	adding        bool
+	inputs        []textinput.Model
+	focusedInput  int
+	status        string
+	width, height int
+}
+
+func NewSubscriptions(cfg utils.Config, width, height int) *SubscriptionsModel {
+	m := &SubscriptionsModel{appConfig: cfg, width: width, height: height}
+
+	d := list.NewDefaultDelegate()
+	c := lipgloss.Color("6")
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(c).BorderLeftForeground(c)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle.Copy()
+
+	m.list = list.New([]list.Item{}, d, width-10, height/2)
+	m.list.SetShowHelp(false)
+	m.list.Title = "Subscriptions"
+	m.list.Styles.Title = ListTitle
+	m.list.Styles.ActivePaginationDot = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+	m.refresh()
+
+	placeholders := []string{
+		"Channel or playlist url",
+		"Preset",
+		"Only titles matching (regular expression)",
+		"Minimum duration in seconds",
+		"Uploaded on or after (YYYYMMDD)",
+	}
+	for _, placeholder := range placeholders {
+		input := textinput.New()
+		input.Placeholder = placeholder
+		m.inputs = append(m.inputs, input)
+	}
+
+	return m
+}
+
+// refresh reloads the subscriptions from the database.
+func (m *SubscriptionsModel) refresh() {
+	subs, err := data.GetAllSubscriptions()
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+
+	items := []list.Item{}
+	for _, subscription := range subs {
+		items = append(items, SubscriptionItem{subscription: subscription})
+	}
+	m.list.SetItems(items)
+}
+
+func (m *SubscriptionsModel) Init() tea.Cmd {
+	return nil
+}
+
+// check polls a single subscription in the background.
+func (m *SubscriptionsModel) check(subscription *data.Subscription) tea.Cmd {
+	cfg := m.appConfig
+	return func() tea.Msg {
+		added, err := subscriptions.Check(cfg, subscription)
+		return subscriptionChecked{url: subscription.Url, added: added, err: err}
+	}
+}
+
+// startAdding shows the form for a new subscription.
+func (m *SubscriptionsModel) startAdding() tea.Cmd {
+	m.adding = true
+	m.focusedInput = subscriptionUrl
+	for i := range m.inputs {
+		m.inputs[i].Reset()
+		m.inputs[i].Blur()
+	}
+	m.inputs[subscriptionUrl].Focus()
+	return textinput.Blink
+}
+
+// save validates the add form and stores the new subscription.
+func (m *SubscriptionsModel) save() {
+	minDuration := 0
+	if value := strings.TrimSpace(m.inputs[subscriptionMinDuration].Value()); len(value) > 0 {
+		var err error
+		minDuration, err = strconv.Atoi(value)
+		if err != nil {
+			m.status = fmt.Sprintf("invalid minimum duration %q", value)
+			return
+		}
+	}
+
+	subscription, err := subscriptions.New(
+		m.appConfig,
+		strings.TrimSpace(m.inputs[subscriptionUrl].Value()),
+		strings.TrimSpace(m.inputs[subscriptionPreset].Value()),
+		m.inputs[subscriptionTitle].Value(),
+		minDuration,
+		strings.TrimSpace(m.inputs[subscriptionDateAfter].Value()),
+	)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+
+	if _, err := data.InsertSubscription(subscription); err != nil {
+		m.status = err.Error()
+		return
+	}
+
+	m.adding = false
+	m.status = fmt.Sprintf("subscribed to %s", subscription.Url)
+	m.refresh()
+}
+
+func (m *SubscriptionsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	dashboardCmd := forwardToDashboard(msg)
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, dashboardCmd)
+}
+
+func (m *SubscriptionsModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case subscriptionChecked:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		} else {
+			m.status = fmt.Sprintf("%s: queued %d new videos", msg.url, msg.added)
+		}
+		m.refresh()
+		return m, func() tea.Msg { return listsChanged{} }
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.list.SetSize(msg.Width-10, msg.Height/2)
+	case tea.KeyMsg:
+		if key.Matches(msg, DefaultSubscriptionsKeyMap.Quit) {
+			return Models[Info], nil
+		}
+
+		if m.adding {
+			switch {
+			case key.Matches(msg, DefaultSubscriptionsKeyMap.Back):
+				m.adding = false
+				m.status = ""
+				return m, nil
+			case key.Matches(msg, DefaultSubscriptionsKeyMap.Tab):
+				m.inputs[m.focusedInput].Blur()
+				m.focusedInput++
+				if m.focusedInput == len(m.inputs) {
+					m.focusedInput = subscriptionUrl
+					m.save()
+					return m, nil
+				}
+				m.inputs[m.focusedInput].Focus()
+				return m, textinput.Blink
+			}
+			m.inputs[m.focusedInput], cmd = m.inputs[m.focusedInput].Update(msg)
+			return m, cmd
+		}
+
+		if m.list.SettingFilter() {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, DefaultSubscriptionsKeyMap.Back):
+			Models[Subscriptions] = m
+			return Models[Info], func() tea.Msg { return listsChanged{} }
+		case key.Matches(msg, DefaultSubscriptionsKeyMap.New):
+			return m, m.startAdding()
+		case key.Matches(msg, DefaultSubscriptionsKeyMap.Delete):
+			if item, ok := m.list.SelectedItem().(SubscriptionItem); ok {
+				if err := data.DeleteSubscription(item.subscription.Id); err != nil {
+					m.status = err.Error()
+				}
+				m.refresh()
+			}
+			return m, nil
+		case key.Matches(msg, DefaultSubscriptionsKeyMap.Check):
+			if item, ok := m.list.SelectedItem().(SubscriptionItem); ok {
+				m.status = fmt.Sprintf("checking %s…", item.subscription.Url)
+				return m, m.check(item.subscription)
+			}
+			return m, nil
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m *SubscriptionsModel) formView() string {
+	fields := []string{}
+	for _, input := range m.inputs {
+		fields = append(fields, input.View())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		TitleStyle.Render("New subscription"),
+		FormStyle.Render(lipgloss.JoinVertical(lipgloss.Left, fields...)),
+	)
+}
+
+func (m *SubscriptionsModel) helpView() string {
+	help := "\n n: new subscription • d: delete • r: check now • esc: back • ctrl+c: quit\n"
+	if m.adding {
+		help = "\n tab/enter: next field/save • esc: cancel • ctrl+c: quit\n"
+	}
+	interval := fmt.Sprintf(" Subscriptions are checked every %d minutes while telecharger is running.\n", m.appConfig.Settings.SubscriptionInterval)
+	if m.appConfig.Settings.SubscriptionInterval <= 0 {
+		interval = " Automatic checks are off, set subscription_interval to turn them on.\n"
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(help + interval)
+}
+
+func (m *SubscriptionsModel) View() string {
+	oneWide := int(float64(m.width - 8))
+
+	content := ListViewStyle.Render(m.list.View())
+	if m.adding {
+		content = m.formView()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		ContainerStyle.Width(oneWide).Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				content,
+				FormStyle.Render(WarningStyle.Render(m.status)),
+			),
+		),
+		HelpContainerStyle.Width(oneWide).Render(
+			m.helpView(),
+		),
+	)
+}
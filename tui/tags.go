@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jim-at-jibba/telecharger/data"
+)
+
+// allTags is the tag picker's first choice, which shows every item.
+const allTags = "All tags"
+
+// openTagPicker lists the tags the dashboard lists can be limited to.
+func (m *model) openTagPicker() {
+	tags, err := data.GetAllTags()
+	if err != nil {
+		m.historyStatus = fmt.Sprintf("can't load tags: %v", err)
+	}
+	m.tags = append([]string{allTags}, tags...)
+	m.tagPicker = true
+	m.tagChoice = 0
+	for i, tag := range tags {
+		if tag == m.tagFilter {
+			m.tagChoice = i + 1
+		}
+	}
+}
+
+func (m *model) updateTagPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, DefaultKeyMap.Up):
+		if m.tagChoice > 0 {
+			m.tagChoice--
+		}
+	case key.Matches(msg, DefaultKeyMap.Down):
+		if m.tagChoice < len(m.tags)-1 {
+			m.tagChoice++
+		}
+	case key.Matches(msg, DefaultKeyMap.Back):
+		m.tagPicker = false
+	case key.Matches(msg, DefaultKeyMap.Enter):
+		m.tagPicker = false
+		m.tagFilter = ""
+		m.doneLoaded = 0
+		if m.tagChoice > 0 {
+			m.tagFilter = m.tags[m.tagChoice]
+		}
+		m.initLists(m.width, m.height)
+	}
+	return m, nil
+}
+
+// filterTag leaves out the items without the dashboard's tag filter, if one
+// is set.
+func (m *model) filterTag(items []list.Item) []list.Item {
+	if len(m.tagFilter) == 0 {
+		return items
+	}
+	filtered := []list.Item{}
+	for _, item := range items {
+		if item.(QueueItem).hasTag(m.tagFilter) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// listTitle adds the tag filter, if one is set, to the title of a list.
+func (m *model) listTitle(title string) string {
+	if len(m.tagFilter) == 0 {
+		return title
+	}
+	return title + " #" + m.tagFilter
+}
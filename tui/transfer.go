@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jim-at-jibba/telecharger/data"
+	"github.com/jim-at-jibba/telecharger/queuefile"
+	utils "github.com/jim-at-jibba/telecharger/utils"
+)
+
+var exportAction = bulkAction{
+	name: "Export",
+	run: func(m *model, items []QueueItem) tea.Cmd {
+		path, err := m.exportItems(items)
+		if err != nil {
+			m.transferStatus = WarningStyle.Render(fmt.Sprintf("export failed: %v", err))
+		} else {
+			m.transferStatus = fmt.Sprintf("exported %d items to %s", len(items), path)
+		}
+		return nil
+	},
+}
+
+// exportItems writes items as JSON to a timestamped file in the download
+// folder, returning the path of the file.
+func (m *model) exportItems(items []QueueItem) (string, error) {
+	exported := []*data.QueueItem{}
+	for _, item := range items {
+		queueItem, err := data.GetQueueItem(item.id)
+		if err != nil {
+			continue
+		}
+		exported = append(exported, queueItem)
+	}
+
+	name := fmt.Sprintf("telecharger-export-%s.json", time.Now().Format("20060102-150405"))
+	path := filepath.Join(utils.ExpandHome(m.appConfig.Settings.DownloadFolder), name)
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return path, queuefile.Export(file, queuefile.JSON, exported)
+}
+
+// openImportPrompt asks for the file to import.
+func (m *model) openImportPrompt() tea.Cmd {
+	m.importPath = textinput.New()
+	m.importPath.Placeholder = "~/queue.json, ~/queue.csv or a batch file of URLs"
+	m.importPath.CharLimit = 250
+	m.importPath.Width = 50
+	m.importPrompt = true
+	return m.importPath.Focus()
+}
+
+// updateImportPrompt reads the chosen file and asks for confirmation with a
+// summary of what the import would add.
+func (m *model) updateImportPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, DefaultKeyMap.Back):
+		m.importPrompt = false
+		return m, nil
+	case key.Matches(msg, DefaultKeyMap.Enter):
+		m.importPrompt = false
+		path := utils.ExpandHome(strings.TrimSpace(m.importPath.Value()))
+		if len(path) == 0 {
+			return m, nil
+		}
+
+		plan, err := m.planImport(path)
+		if err != nil {
+			m.transferStatus = WarningStyle.Render(fmt.Sprintf("import failed: %v", err))
+			return m, nil
+		}
+		if len(plan.Add) == 0 {
+			m.transferStatus = fmt.Sprintf("nothing to import from %s, %s", filepath.Base(path), plan.Summary())
+			return m, nil
+		}
+
+		m.dialogChoice = yes
+		m.confirm = &confirmation{
+			question: fmt.Sprintf("Import %s?\n%s", filepath.Base(path), plan.Summary()),
+			action: func() tea.Cmd {
+				if err := plan.Apply(); err != nil {
+					m.transferStatus = WarningStyle.Render(fmt.Sprintf("import failed: %v", err))
+				} else {
+					m.transferStatus = fmt.Sprintf("imported %s", plan.Summary())
+				}
+				return nil
+			},
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.importPath, cmd = m.importPath.Update(msg)
+	return m, cmd
+}
+
+// planImport works out what importing the file at path would add.
+func (m model) planImport(path string) (queuefile.Plan, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return queuefile.Plan{}, err
+	}
+	defer file.Close()
+
+	records, err := queuefile.Read(file, queuefile.FormatFromPath(path))
+	if err != nil {
+		return queuefile.Plan{}, err
+	}
+	return queuefile.NewPlan(m.appConfig, records, "")
+}
+
+// importPromptView shows the path input of an import in the style of the
+// dialog view.
+func (m model) importPromptView() string {
+	ui := lipgloss.JoinVertical(lipgloss.Center,
+		lipgloss.NewStyle().Width(50).Align(lipgloss.Center).Render("Import a queue export or yt-dlp batch file"),
+		lipgloss.NewStyle().Width(50).MarginTop(1).Render(m.importPath.View()),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("241")).MarginTop(1).Render("enter: preview import • esc: cancel"),
+	)
+
+	return lipgloss.Place(m.width, 10,
+		lipgloss.Center, lipgloss.Center,
+		DialogBoxStyle.Render(ui),
+	)
+}
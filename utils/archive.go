@@ -0,0 +1,155 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchivesDir is the name of the directory under the app data dir that holds a
+// yt-dlp download archive for each preset.
+const ArchivesDir = "archives"
+
+// defaultArchiveName is the archive used by items without a preset.
+const defaultArchiveName = "default"
+
+// ArchivePath returns the download archive used for items with the given preset.
+func ArchivePath(preset string) (string, error) {
+	dir, err := AppDataDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := preset
+	if len(name) == 0 {
+		name = defaultArchiveName
+	}
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+
+	path := filepath.Join(dir, ArchivesDir, fmt.Sprintf("%s.txt", name))
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// ReadArchive returns the entries of the download archive for preset.
+func ReadArchive(preset string) ([]string, error) {
+	path, err := ArchivePath(preset)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readArchiveEntries(file)
+}
+
+// readArchiveEntries reads the non-empty lines of an archive.
+func readArchiveEntries(r io.Reader) ([]string, error) {
+	entries := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+
+	return entries, scanner.Err()
+}
+
+// writeArchive replaces the download archive for preset with entries.
+func writeArchive(preset string, entries []string) error {
+	path, err := ArchivePath(preset)
+	if err != nil {
+		return err
+	}
+
+	content := strings.Join(entries, "\n")
+	if len(entries) > 0 {
+		content += "\n"
+	}
+
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// ExportArchive writes the download archive for preset to w.
+func ExportArchive(preset string, w io.Writer) error {
+	entries, err := ReadArchive(preset)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := fmt.Fprintln(w, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportArchive merges the entries read from r into the download archive for
+// preset and returns how many were new.
+func ImportArchive(preset string, r io.Reader) (int, error) {
+	existing, err := ReadArchive(preset)
+	if err != nil {
+		return 0, err
+	}
+
+	incoming, err := readArchiveEntries(r)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range existing {
+		seen[entry] = true
+	}
+
+	added := 0
+	for _, entry := range incoming {
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		existing = append(existing, entry)
+		added++
+	}
+
+	return added, writeArchive(preset, existing)
+}
+
+// RemoveFromArchive drops an entry from the download archive for preset so
+// yt-dlp will download it again.
+func RemoveFromArchive(preset, entry string) error {
+	entries, err := ReadArchive(preset)
+	if err != nil {
+		return err
+	}
+
+	kept := []string{}
+	for _, existing := range entries {
+		if existing != entry {
+			kept = append(kept, existing)
+		}
+	}
+	if len(kept) == len(entries) {
+		return nil
+	}
+
+	return writeArchive(preset, kept)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
 
 	"gopkg.in/yaml.v3"
@@ -19,13 +20,87 @@ const ConfigFileName = "telecharger.yml"
 
 // SettingsConfig struct represents the config for the settings.
 type SettingsConfig struct {
-	EnableLogging  bool   `yaml:"enable_logging"`
-	DownloadFolder string `yaml:"download_folder"`
+	EnableLogging        bool              `yaml:"enable_logging"`
+	DownloadFolder       string            `yaml:"download_folder"`
+	MinFreeSpace         string            `yaml:"min_free_space"`
+	DatabasePath         string            `yaml:"database_path"`
+	DownloadArchive      bool              `yaml:"download_archive"`
+	YtdlpPath            string            `yaml:"ytdlp_path"`
+	SubscriptionInterval int               `yaml:"subscription_interval"`
+	FeedInterval         int               `yaml:"feed_interval"`
+	AutoStart            bool              `yaml:"auto_start"`
+	DefaultPreset        string            `yaml:"default_preset"`
+	WatchClipboard       bool              `yaml:"watch_clipboard"`
+	TagFolders           bool              `yaml:"tag_folders"`
+	HistoryKeepItems     int               `yaml:"history_keep_items"`
+	HistoryKeepDays      int               `yaml:"history_keep_days"`
+	HistoryArchive       bool              `yaml:"history_archive"`
+	DownloadWindows      []string          `yaml:"download_windows"`
+	RateLimit            string            `yaml:"rate_limit"`
+	RateLimitSchedule    []RateLimitPeriod `yaml:"rate_limit_schedule"`
+	Network              NetworkConfig     `yaml:"network"`
+}
+
+// ValidateDownloadFolder checks that downloads can be written to folder.
+func ValidateDownloadFolder(folder string) error {
+	info, err := os.Stat(ExpandHome(folder))
+	if err != nil {
+		return fmt.Errorf("download folder: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("download folder %s is not a directory", folder)
+	}
+
+	probe, err := os.CreateTemp(ExpandHome(folder), ".telecharger-*")
+	if err != nil {
+		return fmt.Errorf("download folder %s is not writable: %v", folder, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// RateLimitPeriod replaces the global rate limit during a download window,
+// written like the download_windows entries.
+type RateLimitPeriod struct {
+	Window string `yaml:"window"`
+	Limit  string `yaml:"limit"`
+}
+
+// PresetConfig represents a named set of download options that can be applied to queue items.
+type PresetConfig struct {
+	Name           string        `yaml:"name"`
+	AudioOnly      bool          `yaml:"audio_only"`
+	AudioFormat    string        `yaml:"audio_format"`
+	EmbedThumbnail bool          `yaml:"embed_thumbnail"`
+	ExtraCommands  string        `yaml:"extra_commands"`
+	Network        NetworkConfig `yaml:"network,omitempty"`
+}
+
+// FeedConfig represents an RSS or Atom feed that new downloads are queued from.
+type FeedConfig struct {
+	URL        string `yaml:"url"`
+	Preset     string `yaml:"preset"`
+	TitleRegex string `yaml:"title_regex"`
+	After      string `yaml:"after"`
+	Enclosures bool   `yaml:"enclosures"`
 }
 
 // Config represents the main config for the application.
 type Config struct {
 	Settings SettingsConfig `yaml:"settings"`
+	Presets  []PresetConfig `yaml:"presets"`
+	Feeds    []FeedConfig   `yaml:"feeds"`
+}
+
+// Preset returns the preset with the given name.
+func (c Config) Preset(name string) (PresetConfig, bool) {
+	for _, preset := range c.Presets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+
+	return PresetConfig{}, false
 }
 
 // configError represents an error that occurred while parsing the config file.
@@ -42,8 +117,25 @@ type ConfigParser struct{}
 func (parser ConfigParser) getDefaultConfig() Config {
 	return Config{
 		Settings: SettingsConfig{
-			EnableLogging:  false,
-			DownloadFolder: ".",
+			EnableLogging:        false,
+			DownloadFolder:       ".",
+			MinFreeSpace:         "1G",
+			DownloadArchive:      false,
+			YtdlpPath:            "yt-dlp",
+			SubscriptionInterval: 60,
+			FeedInterval:         60,
+		},
+		Presets: []PresetConfig{
+			{
+				Name:           "audio",
+				AudioOnly:      true,
+				AudioFormat:    "mp3",
+				EmbedThumbnail: true,
+			},
+			{
+				Name:           "video",
+				EmbedThumbnail: true,
+			},
 		},
 	}
 }
@@ -97,9 +189,17 @@ func (parser ConfigParser) createConfigFileIfMissing(configFilePath string) erro
 	return nil
 }
 
-// getConfigFileOrCreateIfMissing returns the config file path or creates the config file if it doesn't exist.
-func (parser ConfigParser) getConfigFileOrCreateIfMissing() (*string, error) {
-	var err error
+// configFile is the config file given with --config, used instead of the one
+// in the config directory.
+var configFile string
+
+// SetConfigFile makes telecharger read and write the config file at path.
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+// userConfigDir returns the directory that holds the telecharger config directory.
+func userConfigDir() (string, error) {
 	var configDir string
 
 	operatingSystem := runtime.GOOS
@@ -117,19 +217,42 @@ func (parser ConfigParser) getConfigFileOrCreateIfMissing() (*string, error) {
 	}
 
 	if configDir == "" {
-		configDir, err = os.UserConfigDir()
-		if err != nil {
-			return nil, configError{parser: parser, configDir: configDir, err: err}
+		return os.UserConfigDir()
+	}
+	return configDir, nil
+}
+
+// getConfigFileOrCreateIfMissing returns the config file path or creates the config file if it doesn't exist.
+func (parser ConfigParser) getConfigFileOrCreateIfMissing() (*string, error) {
+	var err error
+	var configDir string
+
+	// a file given on the command line has to exist, see InitConfigFile
+	if len(configFile) > 0 {
+		if _, err := os.Stat(configFile); err != nil {
+			return nil, err
 		}
+		return &configFile, nil
+	}
+
+	configDir, err = userConfigDir()
+	if err != nil {
+		return nil, configError{parser: parser, configDir: configDir, err: err}
 	}
 
+	// profiles other than the default one keep their config files side by side
 	prsConfigDir := filepath.Join(configDir, AppDir)
+	configFileName := ConfigFileName
+	if profile != DefaultProfile {
+		prsConfigDir = filepath.Join(prsConfigDir, ProfilesDir)
+		configFileName = profile + ".yml"
+	}
 	err = os.MkdirAll(prsConfigDir, os.ModePerm)
 	if err != nil {
 		return nil, configError{parser: parser, configDir: configDir, err: err}
 	}
 
-	configFilePath := filepath.Join(prsConfigDir, ConfigFileName)
+	configFilePath := filepath.Join(prsConfigDir, configFileName)
 	err = parser.createConfigFileIfMissing(configFilePath)
 	if err != nil {
 		return nil, configError{parser: parser, configDir: configDir, err: err}
@@ -145,9 +268,16 @@ type parsingError struct {
 
 // Error represents an error that occurred while parsing the config file.
 func (e parsingError) Error() string {
+	if _, ok := e.err.(ConfigIssues); ok {
+		return fmt.Sprintf("invalid %s:\n%v", ConfigFileName, e.err)
+	}
 	return fmt.Sprintf("failed parsing config.yml: %v", e.err)
 }
 
+func (e parsingError) Unwrap() error {
+	return e.err
+}
+
 // readConfigFile reads the config file and returns the config.
 func (parser ConfigParser) readConfigFile(path string) (Config, error) {
 	config := parser.getDefaultConfig()
@@ -156,8 +286,33 @@ func (parser ConfigParser) readConfigFile(path string) (Config, error) {
 		return config, configError{parser: parser, configDir: path, err: err}
 	}
 
-	err = yaml.Unmarshal((data), &config)
-	return config, err
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return config, err
+	}
+	if len(document.Content) > 0 {
+		if issues := checkConfigNode(document.Content[0], reflect.TypeOf(config), ""); len(issues) > 0 {
+			return config, issues
+		}
+		if err := document.Decode(&config); err != nil {
+			return config, err
+		}
+	}
+
+	overridden, issues := applyEnvOverrides(&config.Settings, os.LookupEnv)
+	for _, issue := range checkConfigValues(config) {
+		if env, ok := overridingEnv(overridden, issue.Path); ok {
+			issue.Path = env
+		} else {
+			issue.Line = configLine(&document, issue.Path)
+		}
+		issues = append(issues, issue)
+	}
+	if len(issues) > 0 {
+		return config, issues
+	}
+
+	return config, nil
 }
 
 // initParser initializes the parser.
@@ -165,6 +320,41 @@ func initParser() ConfigParser {
 	return ConfigParser{}
 }
 
+// InitConfigFile writes the default config to the config file, refusing to
+// replace an existing file unless force is set.
+func InitConfigFile(force bool) (string, error) {
+	parser := initParser()
+
+	path := configFile
+	if len(path) == 0 {
+		configFilePath, err := parser.getConfigFileOrCreateIfMissing()
+		if err != nil {
+			return "", err
+		}
+		path = *configFilePath
+		// the default location is created on first run, so only a file
+		// that differs from the defaults counts as existing
+		if contents, err := os.ReadFile(path); err == nil && string(contents) == parser.getDefaultConfigYamlContents() {
+			return path, nil
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		if os.IsExist(err) {
+			return path, fmt.Errorf("%s already exists, use -force to replace it", path)
+		}
+		return path, err
+	}
+	defer file.Close()
+
+	return path, parser.writeDefaultConfigContents(file)
+}
+
 // ParseConfig parses the config file and returns the config.
 func ParseConfig() (Config, error) {
 	var config Config
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFilePath returns the path of the config file, creating it if it doesn't exist.
+func ConfigFilePath() (string, error) {
+	configFilePath, err := initParser().getConfigFileOrCreateIfMissing()
+	if err != nil {
+		return "", err
+	}
+	return *configFilePath, nil
+}
+
+// ConfigValue is a value to write to the config file under Keys, such as
+// []string{"settings", "network"}.
+type ConfigValue struct {
+	Keys  []string
+	Value interface{}
+}
+
+// SetConfigValue replaces the value under keys in the config file, leaving the
+// rest of the file and its comments as they were.
+func SetConfigValue(keys []string, value interface{}) error {
+	return SetConfigValues(ConfigValue{Keys: keys, Value: value})
+}
+
+// SetConfigValues replaces several values in the config file at once, see
+// SetConfigValue.
+func SetConfigValues(values ...ConfigValue) error {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return parsingError{err: err}
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	for _, value := range values {
+		if err := setNodeValue(document.Content[0], value.Keys, value.Value); err != nil {
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, out.Bytes(), 0666)
+}
+
+// setNodeValue replaces the value under keys in a mapping node.
+func setNodeValue(node *yaml.Node, keys []string, value interface{}) error {
+	for _, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("can't set %s in the config file, %q is not a mapping", strings.Join(keys, "."), key)
+		}
+		node = mappingValue(node, key)
+	}
+
+	var replacement yaml.Node
+	if err := replacement.Encode(value); err != nil {
+		return err
+	}
+	// keep any comments written next to the old value
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	*node = replacement
+	return nil
+}
+
+// mappingValue returns the value for key in a mapping node, adding an empty
+// mapping under key when it is missing.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value := mapping.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				value.Kind = yaml.MappingNode
+				value.Tag = ""
+				value.Value = ""
+			}
+			return value
+		}
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var migrateOnce sync.Once
+
+// dataHome returns the base directory for user data, XDG_DATA_HOME or its
+// default of ~/.local/share.
+func dataHome() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); len(dir) > 0 {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// legacyAppDataDir is where telecharger kept its data before it followed the
+// XDG base directories.
+func legacyAppDataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, AppDir), nil
+}
+
+// DatabaseFile is the name of the database file in the data directory.
+const DatabaseFile = "sqlite-database.db"
+
+// appDataEntries are the files and folders telecharger keeps in its data
+// directory. Only these are moved out of the legacy directory, which may hold
+// other things such as downloads.
+var appDataEntries = []string{
+	DatabaseFile,
+	DatabaseFile + "-journal",
+	DatabaseFile + "-wal",
+	DatabaseFile + "-shm",
+	LogsDir,
+	ArchivesDir,
+	ProfilesDir,
+}
+
+// legacyEntries returns the entries of appDataEntries found in the legacy
+// directory.
+func legacyEntries(legacy string) []string {
+	found := []string{}
+	for _, name := range appDataEntries {
+		if _, err := os.Lstat(filepath.Join(legacy, name)); err == nil {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// migrateAppDataDir moves telecharger's files out of the legacy directory to
+// dir the first time dir is used, leaving the legacy directory and anything
+// else in it where it is. It returns the directory to use, which stays the
+// legacy one when the files can't all be moved.
+func migrateAppDataDir(dir string) string {
+	legacy, err := legacyAppDataDir()
+	if err != nil || legacy == dir {
+		return dir
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	entries := legacyEntries(legacy)
+	if len(entries) == 0 {
+		return dir
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		log.Printf("couldn't move telecharger data from %s to %s, keeping it where it is: %v", legacy, dir, err)
+		return legacy
+	}
+	for i, name := range entries {
+		if err := os.Rename(filepath.Join(legacy, name), filepath.Join(dir, name)); err != nil {
+			// put back what was moved so the data stays together
+			for _, moved := range entries[:i] {
+				_ = os.Rename(filepath.Join(dir, moved), filepath.Join(legacy, moved))
+			}
+			_ = os.Remove(dir)
+			log.Printf("couldn't move telecharger data from %s to %s, keeping it where it is: %v", legacy, dir, err)
+			return legacy
+		}
+	}
+
+	log.Printf("moved telecharger data from %s to %s", legacy, dir)
+	return dir
+}
+
+// AppDataDir returns the directory telecharger stores the data of the profile
+// in use in, creating it if it doesn't exist. Data from before telecharger
+// followed XDG_DATA_HOME is moved there the first time it's needed.
+func AppDataDir() (string, error) {
+	base, err := dataHome()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(base, AppDir)
+	migrateOnce.Do(func() {
+		path = migrateAppDataDir(path)
+	})
+	if legacy, err := legacyAppDataDir(); err == nil && path != legacy {
+		// a migration that failed earlier in this run leaves the data behind
+		if _, err := os.Stat(path); os.IsNotExist(err) && len(legacyEntries(legacy)) > 0 {
+			path = legacy
+		}
+	}
+
+	if profile != DefaultProfile {
+		path = filepath.Join(path, ProfilesDir, profile)
+	}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return "", fmt.Errorf("can't create the data directory: %v", err)
+	}
+
+	return path, nil
+}
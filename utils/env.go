@@ -0,0 +1,80 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of the environment variables that override
+// settings, such as TELECHARGER_DOWNLOAD_FOLDER or TELECHARGER_NETWORK_PROXY.
+const EnvPrefix = "TELECHARGER_"
+
+// EnvName returns the environment variable that overrides the setting at
+// path, such as "network.proxy".
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// applyEnvOverrides replaces settings with the values of their environment
+// variables. Values other than strings are read as YAML, so lists can be
+// written as ["01:00-07:00", "22:00-23:00"]. It returns the config path of
+// every overridden setting mapped to its variable.
+func applyEnvOverrides(settings *SettingsConfig, lookup func(string) (string, bool)) (map[string]string, ConfigIssues) {
+	overridden := map[string]string{}
+	issues := ConfigIssues{}
+	applyEnvToStruct(reflect.ValueOf(settings).Elem(), "", lookup, overridden, &issues)
+	return overridden, issues
+}
+
+func applyEnvToStruct(value reflect.Value, path string, lookup func(string) (string, bool), overridden map[string]string, issues *ConfigIssues) {
+	for name, field := range yamlFields(value.Type()) {
+		fieldPath := joinConfigPath(path, name)
+		fieldValue := value.FieldByIndex(field.Index)
+
+		if field.Type.Kind() == reflect.Struct {
+			applyEnvToStruct(fieldValue, fieldPath, lookup, overridden, issues)
+			continue
+		}
+
+		env := EnvName(fieldPath)
+		raw, ok := lookup(env)
+		if !ok {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.String {
+			fieldValue.SetString(raw)
+		} else {
+			var node yaml.Node
+			err := yaml.Unmarshal([]byte(raw), &node)
+			if err == nil && len(node.Content) > 0 {
+				if typeIssues := checkConfigNode(node.Content[0], field.Type, env); len(typeIssues) > 0 {
+					*issues = append(*issues, ConfigIssue{Path: env, Message: typeIssues[0].Message})
+					continue
+				}
+				parsed := reflect.New(field.Type)
+				if err = node.Decode(parsed.Interface()); err == nil {
+					fieldValue.Set(parsed.Elem())
+				}
+			}
+			if err != nil {
+				*issues = append(*issues, ConfigIssue{Path: env, Message: err.Error()})
+				continue
+			}
+		}
+		overridden["settings."+fieldPath] = env
+	}
+}
+
+// overridingEnv returns the variable that set the config value at path, which
+// can be inside an overridden setting such as an entry of a list.
+func overridingEnv(overridden map[string]string, path string) (string, bool) {
+	for setting, env := range overridden {
+		if path == setting || strings.HasPrefix(path, setting+"[") || strings.HasPrefix(path, setting+".") {
+			return env, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LogsDir is the name of the directory under the app data dir that holds the
+// yt-dlp output of every download, in a directory for each database.
+const LogsDir = "logs"
+
+// maxLogRotations is how many previous runs of a download are kept alongside
+// the latest log.
+const maxLogRotations = 3
+
+// databaseLogsDir returns the directory holding the logs of the database at
+// database. Queue item ids are only unique within a database, so each one
+// gets its own directory, named after the file and a hash of its full path.
+func databaseLogsDir(database string) (string, error) {
+	dir, err := AppDataDir()
+	if err != nil {
+		return "", err
+	}
+
+	if abs, err := filepath.Abs(database); err == nil {
+		database = abs
+	}
+	sum := sha256.Sum256([]byte(database))
+	name := strings.TrimSuffix(filepath.Base(database), filepath.Ext(database))
+	return filepath.Join(dir, LogsDir, fmt.Sprintf("%s-%x", name, sum[:4])), nil
+}
+
+// DownloadLogPath returns the path of the latest log for the queue item with
+// id in the database at database.
+func DownloadLogPath(database string, id int) (string, error) {
+	dir, err := databaseLogsDir(database)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%d.log", id)), nil
+}
+
+// CreateDownloadLog rotates any previous logs for the queue item with id in
+// the database at database and returns a new file for the run that is about
+// to start.
+func CreateDownloadLog(database string, id int) (*os.File, error) {
+	path, err := DownloadLogPath(database, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	for i := maxLogRotations - 1; i >= 0; i-- {
+		from := path
+		if i > 0 {
+			from = fmt.Sprintf("%s.%d", path, i)
+		}
+		if _, err := os.Stat(from); err == nil {
+			_ = os.Rename(from, fmt.Sprintf("%s.%d", path, i+1))
+		}
+	}
+
+	return os.Create(path)
+}
+
+// ReadDownloadLog returns the latest log for the queue item with id in the
+// database at database.
+func ReadDownloadLog(database string, id int) (string, error) {
+	path, err := DownloadLogPath(database, id)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// DeleteDownloadLogs removes every log kept for the queue item with id in the
+// database at database.
+func DeleteDownloadLogs(database string, id int) {
+	path, err := DownloadLogPath(database, id)
+	if err != nil {
+		return
+	}
+
+	_ = os.Remove(path)
+	for i := 1; i <= maxLogRotations; i++ {
+		_ = os.Remove(fmt.Sprintf("%s.%d", path, i))
+	}
+}
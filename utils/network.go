@@ -0,0 +1,191 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectProxy is the proxy rule value that bypasses the configured proxy.
+const DirectProxy = "direct"
+
+// NetworkConfig represents how yt-dlp connects, either for every download in
+// the settings or overriding them for the downloads of a preset.
+type NetworkConfig struct {
+	Proxy              string      `yaml:"proxy,omitempty"`
+	ProxyRules         []ProxyRule `yaml:"proxy_rules,omitempty"`
+	CookiesFile        string      `yaml:"cookies_file,omitempty"`
+	CookiesFromBrowser string      `yaml:"cookies_from_browser,omitempty"`
+	UserAgent          string      `yaml:"user_agent,omitempty"`
+	SourceAddress      string      `yaml:"source_address,omitempty"`
+}
+
+// ProxyRule sends the downloads from a domain and its subdomains through a
+// different proxy, or through none when the proxy is "direct".
+type ProxyRule struct {
+	Domain string `yaml:"domain"`
+	Proxy  string `yaml:"proxy"`
+}
+
+var proxySchemes = []string{"http", "https", "socks4", "socks4a", "socks5", "socks5h"}
+
+var cookieBrowsers = []string{"brave", "chrome", "chromium", "edge", "firefox", "opera", "safari", "vivaldi", "whale"}
+
+// Override returns n with every option that is set in override replacing its own.
+func (n NetworkConfig) Override(override NetworkConfig) NetworkConfig {
+	if len(override.Proxy) > 0 {
+		n.Proxy = override.Proxy
+	}
+	if len(override.ProxyRules) > 0 {
+		n.ProxyRules = append(append([]ProxyRule{}, override.ProxyRules...), n.ProxyRules...)
+	}
+	if len(override.CookiesFile) > 0 {
+		n.CookiesFile = override.CookiesFile
+		n.CookiesFromBrowser = ""
+	}
+	if len(override.CookiesFromBrowser) > 0 {
+		n.CookiesFromBrowser = override.CookiesFromBrowser
+		n.CookiesFile = ""
+	}
+	if len(override.UserAgent) > 0 {
+		n.UserAgent = override.UserAgent
+	}
+	if len(override.SourceAddress) > 0 {
+		n.SourceAddress = override.SourceAddress
+	}
+	return n
+}
+
+// Network returns the network options for downloads using preset.
+func (c Config) Network(preset string) NetworkConfig {
+	network := c.Settings.Network
+	if p, ok := c.Preset(preset); ok {
+		network = network.Override(p.Network)
+	}
+	return network
+}
+
+// ProxyFor returns the proxy for a download, the first matching rule taking
+// precedence over the proxy option. It returns false when neither applies and
+// an empty proxy when a rule asks for a direct connection.
+func (n NetworkConfig) ProxyFor(rawURL string) (string, bool) {
+	host := ""
+	if u, err := parseURL(rawURL); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+
+	for _, rule := range n.ProxyRules {
+		domain := strings.ToLower(strings.TrimPrefix(rule.Domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			if rule.Proxy == DirectProxy {
+				return "", true
+			}
+			return rule.Proxy, true
+		}
+	}
+
+	return n.Proxy, len(n.Proxy) > 0
+}
+
+// Args returns the yt-dlp options for downloading rawURL.
+func (n NetworkConfig) Args(rawURL string) []string {
+	args := []string{}
+
+	// an empty --proxy makes yt-dlp connect directly, ignoring the environment
+	if proxy, ok := n.ProxyFor(rawURL); ok {
+		args = append(args, "--proxy", proxy)
+	}
+	if len(n.CookiesFile) > 0 {
+		args = append(args, "--cookies", ExpandHome(n.CookiesFile))
+	} else if len(n.CookiesFromBrowser) > 0 {
+		args = append(args, "--cookies-from-browser", n.CookiesFromBrowser)
+	}
+	if len(n.UserAgent) > 0 {
+		args = append(args, "--user-agent", n.UserAgent)
+	}
+	if len(n.SourceAddress) > 0 {
+		args = append(args, "--source-address", n.SourceAddress)
+	}
+
+	return args
+}
+
+// Validate checks the options before they are handed to yt-dlp.
+func (n NetworkConfig) Validate() error {
+	if err := validateProxy(n.Proxy); err != nil {
+		return err
+	}
+	for _, rule := range n.ProxyRules {
+		if len(strings.TrimSpace(rule.Domain)) == 0 {
+			return fmt.Errorf("proxy rule for %q has no domain", rule.Proxy)
+		}
+		if rule.Proxy == DirectProxy {
+			continue
+		}
+		if err := validateProxy(rule.Proxy); err != nil {
+			return fmt.Errorf("proxy rule for %s: %v", rule.Domain, err)
+		}
+	}
+
+	if len(n.CookiesFile) > 0 && len(n.CookiesFromBrowser) > 0 {
+		return fmt.Errorf("use either a cookies file or cookies from a browser, not both")
+	}
+	if len(n.CookiesFile) > 0 {
+		if _, err := os.Stat(ExpandHome(n.CookiesFile)); err != nil {
+			return fmt.Errorf("cookies file: %v", err)
+		}
+	}
+	if len(n.CookiesFromBrowser) > 0 {
+		browser := strings.ToLower(n.CookiesFromBrowser)
+		if i := strings.IndexAny(browser, "+:"); i >= 0 {
+			browser = browser[:i]
+		}
+		if !containsString(cookieBrowsers, browser) {
+			return fmt.Errorf("unknown browser %q for cookies, expected one of %s", browser, strings.Join(cookieBrowsers, ", "))
+		}
+	}
+
+	if len(n.SourceAddress) > 0 && net.ParseIP(n.SourceAddress) == nil {
+		return fmt.Errorf("source address %q is not an IP address", n.SourceAddress)
+	}
+
+	return nil
+}
+
+func validateProxy(proxy string) error {
+	if len(proxy) == 0 {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || len(u.Host) == 0 {
+		return fmt.Errorf("invalid proxy %q, expected a url such as socks5://127.0.0.1:1080", proxy)
+	}
+	if !containsString(proxySchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("unsupported proxy scheme %q, expected one of %s", u.Scheme, strings.Join(proxySchemes, ", "))
+	}
+	return nil
+}
+
+// ExpandHome replaces a leading ~ in path with the home directory.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile used when none is chosen. Its config and data
+// live where they always have, other profiles get a directory of their own.
+const DefaultProfile = "default"
+
+// ProfilesDir is the name of the directory holding the config files and data
+// of the profiles other than the default one.
+const ProfilesDir = "profiles"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// profile is the profile in use.
+var profile = DefaultProfile
+
+// SetProfile switches the config file and data directory to those of name.
+func SetProfile(name string) error {
+	if len(name) == 0 {
+		name = DefaultProfile
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, numbers, - and _", name)
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the name of the profile in use.
+func Profile() string {
+	return profile
+}
+
+// Profiles returns the names of the default profile and of every profile with
+// a config file, sorted by name.
+func Profiles() ([]string, error) {
+	names := []string{DefaultProfile}
+
+	dir, err := userConfigDir()
+	if err != nil {
+		return names, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, AppDir, ProfilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return names, err
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yml" || name == DefaultProfile || !profileNamePattern.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+
+	return names, nil
+}
@@ -0,0 +1,244 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jim-at-jibba/telecharger/diskspace"
+	"github.com/jim-at-jibba/telecharger/rate"
+	"github.com/jim-at-jibba/telecharger/schedule"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigIssue is a problem with a single value of the config.
+type ConfigIssue struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (i ConfigIssue) Error() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %s", i.Path, i.Line, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// ConfigIssues are all the problems found in a config.
+type ConfigIssues []ConfigIssue
+
+func (issues ConfigIssues) Error() string {
+	lines := []string{}
+	for _, issue := range issues {
+		lines = append(lines, issue.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkConfigNode compares a parsed config file with the fields of t, reporting
+// unknown keys and values of the wrong type.
+func checkConfigNode(node *yaml.Node, t reflect.Type, path string) ConfigIssues {
+	issues := ConfigIssues{}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.ShortTag() == "!!null" {
+		return issues
+	}
+
+	wrongType := func(expected string) ConfigIssues {
+		return append(issues, ConfigIssue{Path: path, Line: node.Line, Message: fmt.Sprintf("expected %s, got %q", expected, nodeDescription(node))})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return wrongType("a mapping")
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinConfigPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				issues = append(issues, ConfigIssue{Path: keyPath, Line: key.Line, Message: fmt.Sprintf("unknown key, expected one of %s", strings.Join(sortedKeys(fields), ", "))})
+				continue
+			}
+			issues = append(issues, checkConfigNode(value, field.Type, keyPath)...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return wrongType("a list")
+		}
+		for i, item := range node.Content {
+			issues = append(issues, checkConfigNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!bool" {
+			return wrongType("true or false")
+		}
+	case reflect.Int, reflect.Int64:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!int" {
+			return wrongType("a whole number")
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			return wrongType("a string")
+		}
+	}
+
+	return issues
+}
+
+// nodeDescription is how a value is shown in error messages.
+func nodeDescription(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	}
+	return node.Value
+}
+
+// yamlFields maps the yaml keys of a struct to its fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func sortedKeys(fields map[string]reflect.StructField) []string {
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinConfigPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// configLine returns the line of the value at path in a parsed config file,
+// such as "settings.network.proxy" or "presets[1].name", or 0 when the value
+// isn't in the file.
+func configLine(document *yaml.Node, path string) int {
+	if document == nil || len(document.Content) == 0 {
+		return 0
+	}
+	node := document.Content[0]
+	for _, part := range strings.Split(path, ".") {
+		name, index := part, -1
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			fmt.Sscanf(part[i:], "[%d]", &index)
+		}
+
+		found := false
+		for i := 0; node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				node = node.Content[i+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+		if index >= 0 {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return 0
+			}
+			node = node.Content[index]
+		}
+	}
+	return node.Line
+}
+
+// checkConfigValues reports settings that have the right type but can't be
+// used, such as a negative interval, or a download window or rate limit that
+// doesn't parse.
+func checkConfigValues(config Config) ConfigIssues {
+	issues := ConfigIssues{}
+	add := func(path string, err error) {
+		if err != nil {
+			issues = append(issues, ConfigIssue{Path: path, Message: err.Error()})
+		}
+	}
+
+	settings := config.Settings
+	if settings.SubscriptionInterval < 0 {
+		add("settings.subscription_interval", fmt.Errorf("can't be negative"))
+	}
+	if settings.FeedInterval < 0 {
+		add("settings.feed_interval", fmt.Errorf("can't be negative"))
+	}
+	if _, err := diskspace.ParseSize(settings.MinFreeSpace); err != nil {
+		add("settings.min_free_space", err)
+	}
+	if settings.HistoryKeepItems < 0 {
+		add("settings.history_keep_items", fmt.Errorf("can't be negative"))
+	}
+	if settings.HistoryKeepDays < 0 {
+		add("settings.history_keep_days", fmt.Errorf("can't be negative"))
+	}
+	for i, window := range settings.DownloadWindows {
+		_, err := schedule.ParseWindow(window)
+		add(fmt.Sprintf("settings.download_windows[%d]", i), err)
+	}
+	if _, err := rate.Parse(settings.RateLimit); err != nil {
+		add("settings.rate_limit", err)
+	}
+	for i, period := range settings.RateLimitSchedule {
+		_, err := schedule.ParseWindow(period.Window)
+		add(fmt.Sprintf("settings.rate_limit_schedule[%d].window", i), err)
+		_, err = rate.Parse(period.Limit)
+		add(fmt.Sprintf("settings.rate_limit_schedule[%d].limit", i), err)
+	}
+	add("settings.network", settings.Network.Validate())
+
+	names := map[string]bool{}
+	for i, preset := range config.Presets {
+		path := fmt.Sprintf("presets[%d]", i)
+		if len(strings.TrimSpace(preset.Name)) == 0 {
+			add(path+".name", fmt.Errorf("presets need a name"))
+		} else if names[preset.Name] {
+			add(path+".name", fmt.Errorf("there is already a preset called %q", preset.Name))
+		}
+		names[preset.Name] = true
+		add(path+".network", preset.Network.Validate())
+	}
+
+	if len(settings.DefaultPreset) > 0 && !names[settings.DefaultPreset] {
+		add("settings.default_preset", fmt.Errorf("unknown preset %q", settings.DefaultPreset))
+	}
+
+	for i, feed := range config.Feeds {
+		path := fmt.Sprintf("feeds[%d]", i)
+		if len(strings.TrimSpace(feed.URL)) == 0 {
+			add(path+".url", fmt.Errorf("feeds need a url"))
+		}
+		if len(feed.Preset) > 0 && !names[feed.Preset] {
+			add(path+".preset", fmt.Errorf("unknown preset %q", feed.Preset))
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,192 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// issuePaths returns the issues as "path:line" for comparing in tests.
+func issuePaths(issues ConfigIssues) []string {
+	paths := []string{}
+	for _, issue := range issues {
+		paths = append(paths, fmt.Sprintf("%s:%d", issue.Path, issue.Line))
+	}
+	return paths
+}
+
+func TestCheckConfigNode(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "valid",
+			yaml: "settings:\n  auto_start: true\n  feed_interval: 30\n  download_windows:\n    - 01:00-07:00\npresets:\n  - name: audio\n    audio_only: true\n",
+		},
+		{name: "empty section", yaml: "settings:\n"},
+		{name: "unknown key", yaml: "settings:\n  colour: red\n", want: []string{"settings.colour:2"}},
+		{name: "unknown section", yaml: "setings:\n  auto_start: true\n", want: []string{"setings:1"}},
+		{name: "not a bool", yaml: "settings:\n  auto_start: yes please\n", want: []string{"settings.auto_start:2"}},
+		{name: "not a number", yaml: "settings:\n  feed_interval: often\n", want: []string{"settings.feed_interval:2"}},
+		{name: "not a list", yaml: "settings:\n  download_windows: 01:00-07:00\n", want: []string{"settings.download_windows:2"}},
+		{name: "not a mapping", yaml: "settings: 3\n", want: []string{"settings:1"}},
+		{name: "not a string", yaml: "settings:\n  rate_limit: [1M]\n", want: []string{"settings.rate_limit:2"}},
+		{
+			name: "inside a list",
+			yaml: "presets:\n  - name: audio\n  - name: video\n    audio_only: maybe\n    colour: red\n",
+			want: []string{"presets[1].audio_only:4", "presets[1].colour:5"},
+		},
+		{
+			name: "several at once",
+			yaml: "settings:\n  auto_start: 1\n  rate_limit_schedule:\n    - window: 01:00-07:00\n      limit: 1M\n      days: weekends\n",
+			want: []string{"settings.auto_start:2", "settings.rate_limit_schedule[0].days:6"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(test.yaml), &document); err != nil {
+				t.Fatal(err)
+			}
+			got := issuePaths(checkConfigNode(document.Content[0], reflect.TypeOf(Config{}), ""))
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("found %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckConfigValues(t *testing.T) {
+	presets := []PresetConfig{{Name: "audio"}, {Name: "video"}}
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{name: "empty", config: Config{}},
+		{
+			name: "valid",
+			config: Config{
+				Settings: SettingsConfig{
+					FeedInterval:      30,
+					MinFreeSpace:      "2G",
+					DefaultPreset:     "audio",
+					DownloadWindows:   []string{"01:00-07:00 mon-fri"},
+					RateLimit:         "1M",
+					RateLimitSchedule: []RateLimitPeriod{{Window: "09:00-18:00", Limit: "300K"}},
+				},
+				Presets: presets,
+				Feeds:   []FeedConfig{{URL: "https://example.com/feed.xml", Preset: "video"}},
+			},
+		},
+		{
+			name:   "negative numbers",
+			config: Config{Settings: SettingsConfig{SubscriptionInterval: -1, FeedInterval: -1, HistoryKeepItems: -1, HistoryKeepDays: -1}},
+			want:   []string{"settings.subscription_interval:0", "settings.feed_interval:0", "settings.history_keep_items:0", "settings.history_keep_days:0"},
+		},
+		{name: "free space", config: Config{Settings: SettingsConfig{MinFreeSpace: "lots"}}, want: []string{"settings.min_free_space:0"}},
+		{
+			name:   "download windows",
+			config: Config{Settings: SettingsConfig{DownloadWindows: []string{"01:00-07:00", "nights", "01:00-07:00 monkey"}}},
+			want:   []string{"settings.download_windows[1]:0", "settings.download_windows[2]:0"},
+		},
+		{name: "rate limit", config: Config{Settings: SettingsConfig{RateLimit: "fast"}}, want: []string{"settings.rate_limit:0"}},
+		{
+			name: "rate limit schedule",
+			config: Config{Settings: SettingsConfig{RateLimitSchedule: []RateLimitPeriod{
+				{Window: "09:00-18:00", Limit: "300K"},
+				{Window: "9-5", Limit: "slow"},
+				{Window: "01:00-07:00", Limit: "-1M"},
+			}}},
+			want: []string{"settings.rate_limit_schedule[1].window:0", "settings.rate_limit_schedule[1].limit:0", "settings.rate_limit_schedule[2].limit:0"},
+		},
+		{name: "proxy", config: Config{Settings: SettingsConfig{Network: NetworkConfig{Proxy: "ftp://proxy"}}}, want: []string{"settings.network:0"}},
+		{
+			name:   "presets",
+			config: Config{Presets: []PresetConfig{{Name: "audio"}, {Name: " "}, {Name: "audio"}}},
+			want:   []string{"presets[1].name:0", "presets[2].name:0"},
+		},
+		{
+			name: "unknown presets",
+			config: Config{
+				Settings: SettingsConfig{DefaultPreset: "podcast"},
+				Presets:  presets,
+				Feeds:    []FeedConfig{{URL: "https://example.com/feed.xml", Preset: "podcast"}, {Preset: "audio"}},
+			},
+			want: []string{"settings.default_preset:0", "feeds[0].preset:0", "feeds[1].url:0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := issuePaths(checkConfigValues(test.config))
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("found %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigFileIssues(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		env  map[string]string
+		want []string
+	}{
+		{name: "valid", yaml: "settings:\n  rate_limit: 1M\n"},
+		{
+			name: "values are reported with their line",
+			yaml: "settings:\n  feed_interval: -5\n  rate_limit: fast\n  rate_limit_schedule:\n    - window: 01:00-07:00\n      limit: slow\n",
+			want: []string{"settings.feed_interval:2", "settings.rate_limit:3", "settings.rate_limit_schedule[0].limit:6"},
+		},
+		{
+			name: "type problems stop the values being checked",
+			yaml: "settings:\n  auto_start: sometimes\n  rate_limit: fast\n",
+			want: []string{"settings.auto_start:2"},
+		},
+		{
+			name: "overridden values are reported by their variable",
+			yaml: "settings:\n  rate_limit: 1M\n",
+			env:  map[string]string{EnvName("rate_limit"): "fast"},
+			want: []string{"TELECHARGER_RATE_LIMIT:0"},
+		},
+		{
+			name: "problems inside an overridden list",
+			yaml: "settings:\n  rate_limit_schedule:\n    - window: 01:00-07:00\n      limit: 1M\n",
+			env:  map[string]string{EnvName("rate_limit_schedule"): `[{window: "01:00-07:00", limit: slow}]`},
+			want: []string{"TELECHARGER_RATE_LIMIT_SCHEDULE:0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for env, value := range test.env {
+				t.Setenv(env, value)
+			}
+			path := filepath.Join(t.TempDir(), ConfigFileName)
+			if err := os.WriteFile(path, []byte(test.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ConfigParser{}.readConfigFile(path)
+			var issues ConfigIssues
+			if err != nil && !errors.As(err, &issues) {
+				t.Fatal(err)
+			}
+			got := issuePaths(issues)
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("found %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var youtubeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// youtubeHosts are the hosts that serve YouTube videos.
+var youtubeHosts = map[string]bool{
+	"youtube.com":          true,
+	"m.youtube.com":        true,
+	"music.youtube.com":    true,
+	"youtube-nocookie.com": true,
+	"youtu.be":             true,
+}
+
+// YoutubeID extracts the video id from the many forms a YouTube link takes:
+// watch URLs, youtu.be short links, shorts, embeds, live links and bare ids.
+func YoutubeID(rawURL string) (string, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if youtubeIDPattern.MatchString(rawURL) {
+		return rawURL, true
+	}
+
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return "", false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if !youtubeHosts[host] {
+		return "", false
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var id string
+	switch {
+	case host == "youtu.be":
+		id = segments[0]
+	case len(segments) >= 2 && (segments[0] == "shorts" || segments[0] == "embed" || segments[0] == "live" || segments[0] == "v"):
+		id = segments[1]
+	default:
+		id = u.Query().Get("v")
+	}
+
+	if !youtubeIDPattern.MatchString(id) {
+		return "", false
+	}
+
+	return id, true
+}
+
+// VideoKey returns a normalised key for a URL so the same video is recognised
+// however it was linked. YouTube videos use the "youtube <id>" form yt-dlp
+// writes to download archives, other URLs are reduced to host and path plus any
+// query parameters in a stable order.
+func VideoKey(rawURL string) string {
+	if id, ok := YoutubeID(rawURL); ok {
+		return "youtube " + id
+	}
+
+	u, err := parseURL(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	key := host + strings.TrimSuffix(u.Path, "/")
+
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := []string{}
+	for _, name := range names {
+		params = append(params, name+"="+query.Get(name))
+	}
+	if len(params) > 0 {
+		key += "?" + strings.Join(params, "&")
+	}
+
+	return "url " + key
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// ExtractURLs returns the http and https URLs found in text, such as a pasted
+// message or web page, without the punctuation that usually follows a link.
+func ExtractURLs(text string) []string {
+	urls := []string{}
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		match = strings.TrimRight(match, ".,;:!?)]}")
+		if u, err := url.Parse(match); err == nil && len(u.Hostname()) > 0 {
+			urls = append(urls, match)
+		}
+	}
+	return urls
+}
+
+// parseURL parses a URL, assuming https when the scheme was left off.
+func parseURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	return url.Parse(rawURL)
+}
+
+// trackingParams are query parameters added for analytics that don't change
+// what a link points at.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"ref_src": true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// youtubeParams are the query parameters of a YouTube link that are kept when
+// it is rewritten as a watch URL: the playlist, position in it and start time.
+var youtubeParams = []string{"list", "index", "t"}
+
+// CanonicalURL checks that rawURL is an http or https link, or a bare YouTube
+// video id, and rewrites it in a standard form. YouTube short links, shorts,
+// embeds and live links become watch URLs, and tracking parameters are removed
+// from every link.
+func CanonicalURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if len(rawURL) == 0 {
+		return "", fmt.Errorf("a URL or YouTube video id is required")
+	}
+
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q", rawURL)
+	}
+	if id, ok := YoutubeID(rawURL); ok {
+		canonical := "https://www.youtube.com/watch?v=" + id
+		if !youtubeIDPattern.MatchString(rawURL) {
+			query := u.Query()
+			for _, name := range youtubeParams {
+				if value := query.Get(name); len(value) > 0 {
+					canonical += "&" + name + "=" + url.QueryEscape(value)
+				}
+			}
+		}
+		return canonical, nil
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported link %q, only http and https URLs can be downloaded", rawURL)
+	}
+	host := u.Hostname()
+	if len(host) == 0 || (!strings.Contains(host, ".") && host != "localhost") || strings.ContainsAny(host, " _") {
+		return "", fmt.Errorf("%q is neither a web address nor a YouTube video id", rawURL)
+	}
+
+	// the query is only rewritten when it has tracking parameters, and the
+	// rest is kept byte for byte since signed links depend on it
+	if query, removed := stripTracking(u.RawQuery); removed {
+		u.RawQuery = query
+	}
+	return u.String(), nil
+}
+
+// stripTracking removes the tracking parameters from a raw query, leaving the
+// other parameters untouched and in order. It reports whether any were removed.
+func stripTracking(rawQuery string) (string, bool) {
+	if len(rawQuery) == 0 {
+		return rawQuery, false
+	}
+
+	kept := []string{}
+	removed := false
+	for _, pair := range strings.Split(rawQuery, "&") {
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		name = strings.ToLower(name)
+		if trackingParams[name] || strings.HasPrefix(name, "utm_") {
+			removed = true
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&"), removed
+}
+
+// reservedFileNames can't be used as file names on Windows, whatever the extension.
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidateFileName checks that name can be used as a file name on common file
+// systems. SanitizeFileName turns any text into a valid name.
+func ValidateFileName(name string) error {
+	if i := strings.IndexFunc(name, func(r rune) bool { return strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 }); i >= 0 {
+		return fmt.Errorf("file names can't contain %q", name[i:i+1])
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("%q can't be used as a file name", name)
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") || strings.HasPrefix(name, " ") {
+		return fmt.Errorf("file names can't start with a space or end with a space or dot")
+	}
+	if reservedFileNames[strings.ToUpper(strings.Split(name, ".")[0])] {
+		return fmt.Errorf("%q is a reserved file name on Windows", name)
+	}
+	return nil
+}
+
+// SanitizeFileName replaces characters that aren't allowed in file names on
+// common file systems so a title can be used as an output name.
+func SanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+
+	return strings.TrimSpace(name)
+}
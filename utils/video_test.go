@@ -0,0 +1,73 @@
+package util
+
+import "testing"
+
+func TestVideoKey(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "dQw4w9WgXcQ", want: "youtube dQw4w9WgXcQ"},
+		{url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", want: "youtube dQw4w9WgXcQ"},
+		{url: "https://youtu.be/dQw4w9WgXcQ?si=abc", want: "youtube dQw4w9WgXcQ"},
+		{url: "youtube.com/shorts/dQw4w9WgXcQ", want: "youtube dQw4w9WgXcQ"},
+		{url: "https://m.youtube.com/watch?v=dQw4w9WgXcQ&list=PL1&t=42", want: "youtube dQw4w9WgXcQ"},
+		{url: "https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", want: "youtube dQw4w9WgXcQ"},
+		{url: "https://www.youtube.com/live/dQw4w9WgXcQ", want: "youtube dQw4w9WgXcQ"},
+		{url: "https://www.Vimeo.com/123456/", want: "url vimeo.com/123456"},
+		{url: "https://m.example.com/video?b=2&a=1", want: "url example.com/video?a=1&b=2"},
+		{url: "example.com/video", want: "url example.com/video"},
+		{url: "not a url", want: "not a url"},
+	}
+
+	for _, test := range tests {
+		if got := VideoKey(test.url); got != test.want {
+			t.Errorf("VideoKey(%q) is %q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+		err  bool
+	}{
+		{url: " dQw4w9WgXcQ ", want: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{url: "https://youtu.be/dQw4w9WgXcQ?t=42&si=abc", want: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42"},
+		{url: "https://www.youtube.com/shorts/dQw4w9WgXcQ", want: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{url: "https://www.youtube.com/watch?feature=share&v=dQw4w9WgXcQ&list=PL1&index=3", want: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PL1&index=3"},
+		{url: "https://vimeo.com/123456", want: "https://vimeo.com/123456"},
+		{url: "vimeo.com/123456", want: "https://vimeo.com/123456"},
+		{url: "https://example.com/v?id=1&utm_source=news&fbclid=xyz", want: "https://example.com/v?id=1"},
+		{url: "https://example.com/v?UTM_Campaign=x", want: "https://example.com/v"},
+		// signed links keep their query as it was
+		{url: "https://cdn.example.com/f.mp4?Expires=1&Signature=a%2Bb%3D&Key-Pair-Id=K", want: "https://cdn.example.com/f.mp4?Expires=1&Signature=a%2Bb%3D&Key-Pair-Id=K"},
+		{url: "https://cdn.example.com/f.mp4?b=a%2Bb&utm_medium=x&a=1", want: "https://cdn.example.com/f.mp4?b=a%2Bb&a=1"},
+		{url: "http://localhost:8080/video.mp4", want: "http://localhost:8080/video.mp4"},
+		{url: "", err: true},
+		{url: "   ", err: true},
+		{url: "ftp://example.com/video.mp4", err: true},
+		{url: "just some words", err: true},
+		{url: "notaurl", err: true},
+		{url: "https://under_score.example/video", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			got, err := CanonicalURL(test.url)
+			if test.err {
+				if err == nil {
+					t.Errorf("rewrote it as %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("rewrote it as %q, want %q", got, test.want)
+			}
+		})
+	}
+}
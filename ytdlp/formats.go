@@ -0,0 +1,23 @@
+package ytdlp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AudioFormats are the formats yt-dlp's --audio-format converts to.
+var AudioFormats = []string{"best", "aac", "alac", "flac", "m4a", "mp3", "opus", "vorbis", "wav"}
+
+// ValidateAudioFormat checks that format is one yt-dlp can convert to. An
+// empty format leaves the choice to yt-dlp.
+func ValidateAudioFormat(format string) error {
+	if len(format) == 0 {
+		return nil
+	}
+	for _, f := range AudioFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported audio format %q, expected one of %s", format, strings.Join(AudioFormats, ", "))
+}
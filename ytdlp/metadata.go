@@ -0,0 +1,64 @@
+package ytdlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Metadata is what yt-dlp reports about a video once it has been downloaded.
+type Metadata struct {
+	Title          string  `json:"title"`
+	Uploader       string  `json:"uploader"`
+	Channel        string  `json:"channel"`
+	Duration       float64 `json:"duration"`
+	Thumbnail      string  `json:"thumbnail"`
+	FilePath       string  `json:"filepath"`
+	FileSize       int64   `json:"filesize"`
+	FileSizeApprox float64 `json:"filesize_approx"`
+	Extractor      string  `json:"extractor_key"`
+	WebpageURL     string  `json:"webpage_url"`
+}
+
+// metadataTemplate selects the fields recorded for each download as JSON.
+const metadataTemplate = "%(.{title,uploader,channel,duration,thumbnail,filepath,filesize,filesize_approx,extractor_key,webpage_url})j"
+
+// MetadataArgs returns the arguments that make yt-dlp append the metadata of
+// each finished download to file.
+func MetadataArgs(file string) []string {
+	return []string{"--print-to-file", "after_move:" + metadataTemplate, file}
+}
+
+// ReadMetadata reads the metadata yt-dlp wrote to file, using the last entry
+// when a download produced more than one.
+func ReadMetadata(file string) (Metadata, error) {
+	var metadata Metadata
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return metadata, err
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	if len(lines) == 0 || len(lines[len(lines)-1]) == 0 {
+		return metadata, fmt.Errorf("no metadata was written to %s", file)
+	}
+
+	if err := json.Unmarshal(lines[len(lines)-1], &metadata); err != nil {
+		return metadata, err
+	}
+
+	if len(metadata.Uploader) == 0 {
+		metadata.Uploader = metadata.Channel
+	}
+	if metadata.FileSize == 0 {
+		if info, err := os.Stat(metadata.FilePath); err == nil {
+			metadata.FileSize = info.Size()
+		} else {
+			metadata.FileSize = int64(metadata.FileSizeApprox)
+		}
+	}
+
+	return metadata, nil
+}
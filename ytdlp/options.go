@@ -0,0 +1,361 @@
+package ytdlp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SponsorBlockCategories are the segment categories SponsorBlock knows, "all"
+// and "default" stand for several of them.
+var SponsorBlockCategories = []string{"sponsor", "intro", "outro", "selfpromo", "preview", "filler", "interaction", "music_offtopic", "poi_highlight", "chapter", "all", "default"}
+
+// RemuxFormats are the containers yt-dlp can remux a video into.
+var RemuxFormats = []string{"mp4", "mkv", "webm", "mov", "avi", "flv", "gif", "mka", "m4a", "mp3", "ogg", "opus", "aac", "aiff", "alac", "flac", "vorbis", "wav"}
+
+var (
+	subtitleLangPattern = regexp.MustCompile(`^-?[A-Za-z0-9_.*-]+$`)
+	timeRangePattern    = regexp.MustCompile(`^-?\d+(:\d{1,2}){0,2}(\.\d+)?-(-?\d+(:\d{1,2}){0,2}(\.\d+)?|inf)$`)
+)
+
+// splitOption splits a comma separated option value, dropping empty parts.
+func splitOption(value string) []string {
+	parts := []string{}
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSubtitleLangs checks a comma separated list of subtitle languages.
+func validateSubtitleLangs(value string) error {
+	for _, lang := range splitOption(value) {
+		if !subtitleLangPattern.MatchString(lang) {
+			return fmt.Errorf("invalid subtitle language %q, use codes such as en or en.*", lang)
+		}
+	}
+	return nil
+}
+
+// validateSponsorBlock checks a comma separated list of SponsorBlock categories,
+// which can be excluded from "all" with a leading "-".
+func validateSponsorBlock(value string) error {
+	for _, category := range splitOption(value) {
+		if !containsString(SponsorBlockCategories, strings.TrimPrefix(category, "-")) {
+			return fmt.Errorf("unknown SponsorBlock category %q, expected one of %s", category, strings.Join(SponsorBlockCategories, ", "))
+		}
+	}
+	return nil
+}
+
+// validateDownloadSections checks a comma separated list of time ranges or
+// chapter title regular expressions.
+func validateDownloadSections(value string) error {
+	for _, section := range splitOption(value) {
+		if timeRangePattern.MatchString(section) {
+			continue
+		}
+		// anything made of digits and separators was meant as a time range
+		if len(strings.Trim(strings.TrimSuffix(section, "inf"), "0123456789:.-")) == 0 {
+			return fmt.Errorf("invalid time range %q, use start-end such as 1:30-2:45 or 90-inf", section)
+		}
+		if _, err := regexp.Compile(section); err != nil {
+			return fmt.Errorf("invalid chapter pattern %q: %v", section, err)
+		}
+	}
+	return nil
+}
+
+// validateRemuxVideo checks a container to remux into, or rules such as
+// "webm>mkv/mp4" that pick the container from the downloaded one.
+func validateRemuxVideo(value string) error {
+	for _, rule := range strings.Split(value, "/") {
+		if len(value) == 0 {
+			break
+		}
+		for _, format := range strings.Split(rule, ">") {
+			if !containsString(RemuxFormats, strings.TrimSpace(format)) {
+				return fmt.Errorf("unsupported container %q, expected one of %s", format, strings.Join(RemuxFormats, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// validateFragments checks the number of fragments downloaded at once.
+func validateFragments(value string) error {
+	if n, _ := strconv.Atoi(value); n < 1 {
+		return fmt.Errorf("at least one fragment has to be downloaded at a time")
+	}
+	return nil
+}
+
+// OptionType is the kind of value an option takes, which decides how it is
+// edited and checked.
+type OptionType int
+
+const (
+	// BoolOption is a checkbox, its flag is passed when it is ticked.
+	BoolOption OptionType = iota
+	// EnumOption takes one of the option's choices.
+	EnumOption
+	// StringOption takes free text, checked by the option's Check.
+	StringOption
+	// NumberOption takes a whole number.
+	NumberOption
+)
+
+// Option describes a yt-dlp option that has a field of its own rather than
+// being typed into the extra commands. Options are listed in the registry,
+// which the form, the queue and the exports all work from.
+type Option struct {
+	// Name is the key the value is stored and exported under.
+	Name  string
+	Label string
+	Type  OptionType
+	// Default is what yt-dlp does when the flag isn't passed, so an item that
+	// doesn't set the option gets it without any arguments.
+	Default string
+	// Choices are the values an EnumOption accepts.
+	Choices []string
+	// Flag is the yt-dlp flag, followed by the value unless it's a BoolOption.
+	Flag string
+	Help string
+	// Check, when set, makes further checks on a non-empty value.
+	Check func(value string) error
+	// Args, when set, builds the arguments for a non-empty value instead of Flag.
+	Args func(value string) []string
+}
+
+// Names of the options other packages refer to.
+const (
+	EmbedThumbnail = "embed_thumbnail"
+	AudioOnly      = "audio_only"
+)
+
+// registry holds the options in the order the form shows them.
+var registry = []Option{
+	{Name: EmbedThumbnail, Label: "Embed Thumbnail", Type: BoolOption, Default: "false", Flag: "--embed-thumbnail",
+		Help: "Embed the thumbnail in the file as cover art"},
+	{Name: AudioOnly, Label: "Audio Only", Type: BoolOption, Default: "false", Flag: "--extract-audio",
+		Help: "Keep only the audio, converted to the audio format"},
+	{Name: "embed_subs", Label: "Embed Subtitles", Type: BoolOption, Default: "false", Flag: "--embed-subs",
+		Help: "Embed the downloaded subtitles in the video"},
+	{Name: "auto_subs", Label: "Auto-generated Subtitles", Type: BoolOption, Default: "false", Flag: "--write-auto-subs",
+		Help: "Also download automatically generated subtitles"},
+	{Name: "embed_metadata", Label: "Embed Metadata", Type: BoolOption, Default: "false", Flag: "--embed-metadata",
+		Help: "Write the title, uploader and description into the file"},
+	{Name: "embed_chapters", Label: "Embed Chapters", Type: BoolOption, Default: "false", Flag: "--embed-chapters",
+		Help: "Add chapter markers to the file"},
+	{Name: "write_info_json", Label: "Write Info JSON", Type: BoolOption, Default: "false", Flag: "--write-info-json",
+		Help: "Save the video's metadata in a .info.json file next to it"},
+	{Name: "subtitle_langs", Label: "Subtitles", Type: StringOption, Flag: "--sub-langs",
+		Help: "Subtitle languages (en, fr, en.*)", Check: validateSubtitleLangs,
+		Args: func(value string) []string {
+			return []string{"--write-subs", "--sub-langs", strings.Join(splitOption(value), ",")}
+		}},
+	{Name: "sponsorblock", Label: "SponsorBlock removes", Type: StringOption, Flag: "--sponsorblock-remove",
+		Help: "SponsorBlock categories to remove (sponsor, intro, selfpromo, all)", Check: validateSponsorBlock,
+		Args: func(value string) []string {
+			return []string{"--sponsorblock-remove", strings.Join(splitOption(value), ",")}
+		}},
+	{Name: "download_sections", Label: "Sections", Type: StringOption, Flag: "--download-sections",
+		Help: "Only download sections (1:30-2:45, 10:00-inf or a chapter title)", Check: validateDownloadSections,
+		Args: func(value string) []string {
+			args := []string{}
+			for _, section := range splitOption(value) {
+				// time ranges are marked with * to tell them apart from chapter titles
+				if timeRangePattern.MatchString(section) {
+					section = "*" + section
+				}
+				args = append(args, "--download-sections", section)
+			}
+			return args
+		}},
+	{Name: "remux_video", Label: "Remux", Type: StringOption, Flag: "--remux-video",
+		Help: "Remux into container (mp4, mkv, webm)", Check: validateRemuxVideo},
+	{Name: "merge_output_format", Label: "Merge format", Type: EnumOption, Flag: "--merge-output-format",
+		Choices: []string{"avi", "flv", "mkv", "mov", "mp4", "webm"}, Help: "Container to merge video and audio into"},
+	{Name: "concurrent_fragments", Label: "Concurrent fragments", Type: NumberOption, Default: "1", Flag: "--concurrent-fragments",
+		Help: "Fragments of a stream to download at once", Check: validateFragments},
+}
+
+// Register adds an option to the registry, after the ones already there. It
+// panics when the name is taken, as registering happens at start up.
+func Register(option Option) {
+	if _, ok := LookupOption(option.Name); ok {
+		panic(fmt.Sprintf("ytdlp: option %q registered twice", option.Name))
+	}
+	registry = append(registry, option)
+}
+
+// Registered returns every option in the order they were registered.
+func Registered() []Option {
+	return registry
+}
+
+// LookupOption finds a registered option by name.
+func LookupOption(name string) (Option, bool) {
+	for _, option := range registry {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+// Validate checks value against the option's type and its own checks. An
+// empty value always passes, it stands for the default.
+func (o Option) Validate(value string) error {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil
+	}
+
+	switch o.Type {
+	case BoolOption:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", strings.ToLower(o.Label))
+		}
+	case EnumOption:
+		if !containsString(o.Choices, value) {
+			return fmt.Errorf("unsupported %s %q, expected one of %s", strings.ToLower(o.Label), value, strings.Join(o.Choices, ", "))
+		}
+	case NumberOption:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be a whole number", strings.ToLower(o.Label))
+		}
+	}
+
+	if o.Check != nil {
+		return o.Check(value)
+	}
+	return nil
+}
+
+// args returns the yt-dlp arguments for value.
+func (o Option) args(value string) []string {
+	if o.Type == BoolOption {
+		if on, _ := strconv.ParseBool(value); !on {
+			return nil
+		}
+		if o.Args != nil {
+			return o.Args(value)
+		}
+		return []string{o.Flag}
+	}
+
+	if len(value) == 0 {
+		return nil
+	}
+	if o.Args != nil {
+		return o.Args(value)
+	}
+	return []string{o.Flag, value}
+}
+
+// Options are the values of the registered options for a download, keyed by
+// option name. Options left out take their default.
+type Options map[string]string
+
+// Value returns the value of the named option, or its default.
+func (o Options) Value(name string) string {
+	if value, ok := o[name]; ok {
+		return value
+	}
+	option, _ := LookupOption(name)
+	return option.Default
+}
+
+// Bool reports whether the named bool option is turned on.
+func (o Options) Bool(name string) bool {
+	on, _ := strconv.ParseBool(o.Value(name))
+	return on
+}
+
+// Set stores the value of the named option. Values that are empty or the same
+// as the default are dropped, so only what differs is kept.
+func (o *Options) Set(name, value string) {
+	value = strings.TrimSpace(value)
+	option, _ := LookupOption(name)
+	if len(value) == 0 || value == option.Default {
+		delete(*o, name)
+		return
+	}
+	if *o == nil {
+		*o = Options{}
+	}
+	(*o)[name] = value
+}
+
+// SetBool turns the named bool option on or off.
+func (o *Options) SetBool(name string, on bool) {
+	o.Set(name, strconv.FormatBool(on))
+}
+
+// Clone returns a copy that can be changed without affecting o.
+func (o Options) Clone() Options {
+	clone := Options{}
+	for name, value := range o {
+		clone[name] = value
+	}
+	return clone
+}
+
+// Validate checks every option that is set.
+func (o Options) Validate() error {
+	for name, value := range o {
+		option, ok := LookupOption(name)
+		if !ok {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if err := option.Validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Args returns the yt-dlp arguments for the options that are set, in
+// registry order.
+func (o Options) Args() []string {
+	args := []string{}
+	for _, option := range registry {
+		if value, ok := o[option.Name]; ok {
+			args = append(args, option.args(value)...)
+		}
+	}
+	return args
+}
+
+// Summary describes the options that differ from their default, a line
+// each, for details views.
+func (o Options) Summary() []string {
+	lines := []string{}
+	for _, option := range registry {
+		value, ok := o[option.Name]
+		if !ok {
+			continue
+		}
+		if option.Type == BoolOption {
+			value = "no"
+			if o.Bool(option.Name) {
+				value = "yes"
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", option.Label, value))
+	}
+	return lines
+}
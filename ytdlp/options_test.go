@@ -0,0 +1,121 @@
+package ytdlp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptionsArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{name: "none", options: Options{}},
+		{name: "bools on", options: Options{AudioOnly: "true", EmbedThumbnail: "true"}, want: "--embed-thumbnail --extract-audio"},
+		{name: "bools off", options: Options{AudioOnly: "false", "embed_subs": "false"}},
+		{name: "value", options: Options{"remux_video": "mkv"}, want: "--remux-video mkv"},
+		{name: "enum", options: Options{"merge_output_format": "mp4"}, want: "--merge-output-format mp4"},
+		{name: "number", options: Options{"concurrent_fragments": "4"}, want: "--concurrent-fragments 4"},
+		{name: "empty value", options: Options{"remux_video": ""}},
+		{name: "subtitles", options: Options{"subtitle_langs": "en, fr,,en.*"}, want: "--write-subs --sub-langs en,fr,en.*"},
+		{name: "sponsorblock", options: Options{"sponsorblock": "all, -filler"}, want: "--sponsorblock-remove all,-filler"},
+		{
+			name:    "sections",
+			options: Options{"download_sections": "1:30-2:45, Intro.*,10:00-inf"},
+			want:    "--download-sections *1:30-2:45 --download-sections Intro.* --download-sections *10:00-inf",
+		},
+		{
+			// arguments follow the registry order whatever order the options were set in
+			name:    "registry order",
+			options: Options{"concurrent_fragments": "2", "embed_metadata": "true", AudioOnly: "true"},
+			want:    "--extract-audio --embed-metadata --concurrent-fragments 2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if args := strings.Join(test.options.Args(), " "); args != test.want {
+				t.Errorf("args are %q, want %q", args, test.want)
+			}
+		})
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		err     bool
+	}{
+		{name: "none", options: Options{}},
+		{name: "valid", options: Options{AudioOnly: "true", "subtitle_langs": "en,-live_chat", "remux_video": "webm>mkv/mp4", "concurrent_fragments": "3"}},
+		{name: "empty values", options: Options{"merge_output_format": " ", "concurrent_fragments": ""}},
+		{name: "unknown option", options: Options{"colour": "red"}, err: true},
+		{name: "not a bool", options: Options{AudioOnly: "sometimes"}, err: true},
+		{name: "unknown choice", options: Options{"merge_output_format": "ogg"}, err: true},
+		{name: "not a number", options: Options{"concurrent_fragments": "many"}, err: true},
+		{name: "too few fragments", options: Options{"concurrent_fragments": "0"}, err: true},
+		{name: "bad subtitle language", options: Options{"subtitle_langs": "en us"}, err: true},
+		{name: "unknown sponsorblock category", options: Options{"sponsorblock": "sponsor,ads"}, err: true},
+		{name: "bad time range", options: Options{"download_sections": "1:30-"}, err: true},
+		{name: "bad chapter pattern", options: Options{"download_sections": "Intro("}, err: true},
+		{name: "unknown container", options: Options{"remux_video": "mp4>mpg"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.options.Validate()
+			if test.err && err == nil {
+				t.Error("validated, want an error")
+			}
+			if !test.err && err != nil {
+				t.Errorf("didn't validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestOptionsSet(t *testing.T) {
+	var options Options
+	options.Set("remux_video", " mkv ")
+	options.SetBool(AudioOnly, true)
+	options.Set("concurrent_fragments", "4")
+	if options.Value("remux_video") != "mkv" || !options.Bool(AudioOnly) || options.Value("concurrent_fragments") != "4" {
+		t.Errorf("set %v", options)
+	}
+
+	// values that are empty or the default are dropped
+	options.Set("remux_video", "")
+	options.SetBool(AudioOnly, false)
+	options.Set("concurrent_fragments", "1")
+	if len(options) != 0 {
+		t.Errorf("left %v, want nothing", options)
+	}
+	if options.Value("concurrent_fragments") != "1" || options.Bool(EmbedThumbnail) {
+		t.Error("options that aren't set don't take their default")
+	}
+
+	clone := Options{AudioOnly: "true"}.Clone()
+	clone.SetBool(EmbedThumbnail, true)
+	if len(clone) != 2 {
+		t.Errorf("clone is %v", clone)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registered := registry
+	t.Cleanup(func() { registry = registered })
+
+	Register(Option{Name: "no_mtime", Label: "Keep download time", Type: BoolOption, Default: "false", Flag: "--no-mtime"})
+	if args := strings.Join(Options{"no_mtime": "true", AudioOnly: "true"}.Args(), " "); args != "--extract-audio --no-mtime" {
+		t.Errorf("args are %q", args)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice didn't panic")
+		}
+	}()
+	Register(Option{Name: AudioOnly})
+}
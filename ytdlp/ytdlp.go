@@ -0,0 +1,96 @@
+package ytdlp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Binary is the yt-dlp executable that gets run, replaceable so a different
+// install or a stub can be used.
+var Binary = "yt-dlp"
+
+// Entry is a video listed by a channel or playlist.
+type Entry struct {
+	ID         string  `json:"id"`
+	URL        string  `json:"url"`
+	WebpageURL string  `json:"webpage_url"`
+	Title      string  `json:"title"`
+	Duration   float64 `json:"duration"`
+	UploadDate string  `json:"upload_date"`
+	Timestamp  int64   `json:"timestamp"`
+	IEKey      string  `json:"ie_key"`
+}
+
+// Link returns the best URL to download the entry from.
+func (e Entry) Link() string {
+	if len(e.WebpageURL) > 0 {
+		return e.WebpageURL
+	}
+	if strings.HasPrefix(e.URL, "http") {
+		return e.URL
+	}
+	if e.IEKey == "Youtube" || len(e.URL) == 0 {
+		return fmt.Sprintf("https://www.youtube.com/watch?v=%s", e.ID)
+	}
+	return e.URL
+}
+
+// ListPlaylist returns the entries of a channel or playlist without
+// downloading or fully extracting any of them.
+func ListPlaylist(url string) ([]Entry, error) {
+	cmd := exec.Command(Binary, "--flat-playlist", "--dump-json", "--no-warnings", url) //nolint:gosec
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %v: %s", url, err, strings.TrimSpace(stderr.String()))
+	}
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("parsing yt-dlp output for %s: %v", url, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// sizeTemplate prints the size of a download, exact when the site reports it
+// and estimated otherwise.
+const sizeTemplate = "%(filesize,filesize_approx)d"
+
+// EstimateSize asks yt-dlp how big downloading url with args will be, without
+// downloading anything. The entries of a playlist are added up, and those
+// without a known size count as nothing.
+func EstimateSize(url string, args []string) (int64, error) {
+	probe := append(append([]string{}, args...), "--no-warnings", "--print", sizeTemplate, url)
+	cmd := exec.Command(Binary, probe...) //nolint:gosec
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("estimating the size of %s: %v: %s", url, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var total int64
+	for _, line := range strings.Split(string(output), "\n") {
+		if size, err := strconv.ParseFloat(strings.TrimSpace(line), 64); err == nil && size > 0 {
+			total += int64(size)
+		}
+	}
+	return total, nil
+}